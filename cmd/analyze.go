@@ -0,0 +1,184 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/qepting91/gomain_analysis/internal/evidence"
+	"github.com/qepting91/gomain_analysis/internal/modules"
+	"github.com/qepting91/gomain_analysis/internal/pipeline"
+	"github.com/qepting91/gomain_analysis/internal/report"
+	"github.com/qepting91/gomain_analysis/internal/target"
+
+	"github.com/urfave/cli/v2"
+)
+
+// scanFlags are shared by the commands that run scans and write reports
+func scanFlags() []cli.Flag {
+	return append([]cli.Flag{
+		&cli.StringFlag{
+			Name:  "format",
+			Usage: "Output format: " + strings.Join(report.Formats(), ", "),
+			Value: report.FormatPDF,
+		},
+		&cli.BoolFlag{
+			Name:  "no-store",
+			Usage: "Do not save results in the scan history database",
+		},
+	}, selectionFlags()...)
+}
+
+// selectionFlags choose which modules a scan runs and how long it may take
+func selectionFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:  "profile",
+			Usage: "Scan profile: " + strings.Join(pipeline.Profiles(), ", ") + " (default: from config, else " + pipeline.DefaultProfile + ")",
+		},
+		&cli.StringSliceFlag{
+			Name:  "modules",
+			Usage: "Comma-separated modules to run instead of a profile (" + strings.Join(modules.Names(), ", ") + ")",
+		},
+		&cli.StringSliceFlag{
+			Name:  "skip",
+			Usage: "Comma-separated modules to leave out",
+		},
+		&cli.DurationFlag{
+			Name:  "timeout",
+			Usage: "Overall deadline for a scan (e.g. 5m); modules still running are cancelled",
+		},
+	}
+}
+
+// selectSteps builds the module selection from the scan flags, falling back
+// to the configured profile
+func selectSteps(c *cli.Context) (*pipeline.Selection, error) {
+	cfg := appConfig(c)
+	profile := c.String("profile")
+	if profile == "" {
+		profile = cfg.Profile
+	}
+	return pipeline.Select(pipeline.DefaultSteps(cfg), profile, c.StringSlice("modules"), c.StringSlice("skip"))
+}
+
+func analyzeCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "analyze",
+		Usage: "Perform comprehensive domain analysis",
+		Flags: append([]cli.Flag{
+			&cli.StringFlag{
+				Name:     "domain",
+				Usage:    "Domain, URL or IP to analyze (e.g., example.com, https://www.example.com/path)",
+				Required: true,
+			},
+			&cli.StringFlag{
+				Name:  "output",
+				Usage: "Output file path, or - for stdout (default: named by the output settings in the config)",
+			},
+			&cli.StringFlag{
+				Name:  "evidence",
+				Usage: "Save every raw answer with its source and SHA-256 hash in this empty directory, also packaged as a zip file of the same name next to it",
+			},
+			pushMISPFlag(),
+		}, append(scanFlags(), pivotFlags()...)...),
+		Action: func(c *cli.Context) error {
+			input := c.String("domain")
+			domain, err := target.Normalize(input)
+			if err != nil {
+				return err
+			}
+			format := c.String("format")
+			if err := report.ValidateFormat(format); err != nil {
+				return err
+			}
+			mispPush, err := mispClient(c)
+			if err != nil {
+				return err
+			}
+			outputPath := c.String("output")
+			if outputPath == "" {
+				out := appConfig(c).Output
+				if err := os.MkdirAll(out.Dir, 0o755); err != nil {
+					return fmt.Errorf("failed to create output directory: %v", err)
+				}
+				outputPath = filepath.Join(out.Dir, report.OutputName(out.Filename, domain, format))
+			}
+
+			// Keep stdout clean when the report itself is written there
+			progress := c.App.Writer
+			if outputPath == "-" {
+				progress = c.App.ErrWriter
+			}
+
+			// Cancel on Ctrl-C but still write whatever completed
+			ctx, stop := signal.NotifyContext(c.Context, os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			if timeout := c.Duration("timeout"); timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, timeout)
+				defer cancel()
+			}
+
+			sel, err := selectSteps(c)
+			if err != nil {
+				return err
+			}
+			pivotSel, err := pivotSelection(c, sel)
+			if err != nil {
+				return err
+			}
+			var collector *evidence.Collector
+			if dir := c.String("evidence"); dir != "" {
+				if collector, err = evidence.NewCollector(dir); err != nil {
+					return err
+				}
+				ctx = evidence.WithCollector(ctx, collector)
+			}
+
+			fmt.Fprintf(progress, "\nAnalyzing %s (profile: %s, modules: %s)\n",
+				domain, sel.Profile, strings.Join(pipeline.StepNames(sel.Steps), ", "))
+			r, err := pipeline.Analyze(ctx, input, sel, progress)
+			if err != nil {
+				return err
+			}
+			runPivots(ctx, c, r, pivotSel, progress)
+			stop()
+			if r.Interrupted {
+				fmt.Fprintf(progress, "\nScan interrupted, writing partial report\n")
+			}
+
+			// Generate Report
+			fmt.Fprintf(progress, "\nGenerating %s report\n", strings.ToUpper(format))
+			if err := report.WriteFile(outputPath, format, r); err != nil {
+				return fmt.Errorf("error generating report: %v", err)
+			}
+			if collector != nil {
+				archive, err := collector.Finish(r)
+				if err != nil {
+					return err
+				}
+				fmt.Fprintf(progress, "Saved %d evidence artifact(s) to %s and %s\n", collector.Len(), collector.Dir(), archive)
+			}
+
+			if !c.Bool("no-store") {
+				s, err := openStore(c)
+				if err != nil {
+					return err
+				}
+				defer s.Close()
+				id, err := s.Save(r)
+				if err != nil {
+					return err
+				}
+				fmt.Fprintf(progress, "Saved scan #%d of %s to history\n", id, domain)
+			}
+
+			return pushMISP(c.Context, mispPush, r, progress)
+		},
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/qepting91/gomain_analysis/internal/batch"
+	"github.com/qepting91/gomain_analysis/internal/report"
+	"github.com/qepting91/gomain_analysis/internal/store"
+
+	"github.com/urfave/cli/v2"
+)
+
+func batchCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "batch",
+		Usage:     "Analyze many domains read from a file or stdin",
+		ArgsUsage: "[file]",
+		Flags: append([]cli.Flag{
+			&cli.StringFlag{
+				Name:  "input",
+				Usage: "File with one domain per line, or - for stdin",
+				Value: "-",
+			},
+			&cli.StringFlag{
+				Name:  "output-dir",
+				Usage: "Directory for per-domain reports and the " + batch.IndexFile + " summary",
+				Value: "reports",
+			},
+			&cli.IntFlag{
+				Name:  "workers",
+				Usage: "Number of domains analyzed concurrently",
+				Value: 4,
+			},
+		}, scanFlags()...),
+		Action: func(c *cli.Context) error {
+			format := c.String("format")
+			if err := report.ValidateFormat(format); err != nil {
+				return err
+			}
+			sel, err := selectSteps(c)
+			if err != nil {
+				return err
+			}
+
+			input := c.String("input")
+			if c.Args().Present() {
+				input = c.Args().First()
+			}
+			var src io.Reader = os.Stdin
+			if input != "-" {
+				file, err := os.Open(input)
+				if err != nil {
+					return fmt.Errorf("failed to open domain list: %v", err)
+				}
+				defer file.Close()
+				src = file
+			}
+			domains, err := batch.ReadDomains(src)
+			if err != nil {
+				return err
+			}
+			if len(domains) == 0 {
+				return fmt.Errorf("no domains to analyze")
+			}
+
+			outputDir := c.String("output-dir")
+			if err := os.MkdirAll(outputDir, 0o755); err != nil {
+				return fmt.Errorf("failed to create output directory: %v", err)
+			}
+
+			// Ctrl-C stops dispatching new domains; in-flight ones write partial reports
+			ctx, stop := signal.NotifyContext(c.Context, os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			var s *store.Store
+			if !c.Bool("no-store") {
+				if s, err = openStore(c); err != nil {
+					return err
+				}
+				defer s.Close()
+			}
+
+			runner := &batch.Runner{
+				Selection: sel,
+				Workers:   c.Int("workers"),
+				Timeout:   c.Duration("timeout"),
+				Format:    format,
+				OutputDir: outputDir,
+				FileName:  appConfig(c).Output.Filename,
+				Progress:  c.App.Writer,
+				Store:     s,
+			}
+			fmt.Fprintf(c.App.Writer, "Analyzing %d domains with %d workers (profile: %s)\n", len(domains), runner.Workers, sel.Profile)
+			summary := runner.Run(ctx, domains)
+			stop()
+
+			indexPath := filepath.Join(outputDir, batch.IndexFile)
+			if err := batch.WriteSummary(indexPath, summary); err != nil {
+				return err
+			}
+			fmt.Fprintf(c.App.Writer, "\n%d succeeded, %d partial, %d failed, %d cancelled in %s\nSummary written to %s\n",
+				summary.Succeeded, summary.Partial, summary.Failed, summary.Cancelled, summary.Duration.Round(time.Second), indexPath)
+			return nil
+		},
+	}
+}
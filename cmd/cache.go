@@ -0,0 +1,38 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/qepting91/gomain_analysis/internal/cache"
+
+	"github.com/urfave/cli/v2"
+)
+
+func cacheCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "cache",
+		Usage: "Manage the on-disk cache of third-party answers",
+		Subcommands: []*cli.Command{
+			{
+				Name:  "prune",
+				Usage: "Delete expired cache entries",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "all",
+						Usage: "Delete every entry, not just expired ones",
+					},
+				},
+				Action: func(c *cli.Context) error {
+					cfg := appConfig(c)
+					stats, err := cache.New(cfg.Cache.Dir, cfg.Cache.TTLs()).Prune(c.Bool("all"))
+					if err != nil {
+						return err
+					}
+					fmt.Fprintf(c.App.Writer, "Removed %d entries (%.1f KiB) from %s, %d kept\n",
+						stats.Removed, float64(stats.Freed)/1024, cfg.Cache.Dir, stats.Kept)
+					return nil
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/qepting91/gomain_analysis/internal/audit"
+	"github.com/qepting91/gomain_analysis/internal/cache"
+	"github.com/qepting91/gomain_analysis/internal/config"
+	"github.com/qepting91/gomain_analysis/internal/dns"
+	"github.com/qepting91/gomain_analysis/internal/pipeline"
+	"github.com/qepting91/gomain_analysis/internal/scope"
+	"github.com/qepting91/gomain_analysis/internal/settings"
+	"github.com/qepting91/gomain_analysis/internal/transport"
+
+	"github.com/urfave/cli/v2"
+)
+
+const configKey = "config"
+
+// configFlags are the global flags that locate the config file or override
+// the paths it contains
+func configFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:  "config",
+			Usage: "Path of the YAML config file (default: $" + settings.EnvConfig + ", ./" + settings.FileName + " or ~/.gomain_analysis/config.yaml)",
+		},
+		&cli.StringFlag{
+			Name:  "db",
+			Usage: "Path of the local scan history database (default: ~/.gomain_analysis/history.db)",
+		},
+		&cli.StringFlag{
+			Name:  "geolite-db",
+			Usage: "Path of the GeoLite2 City database",
+		},
+		&cli.StringFlag{
+			Name:  "queries-file",
+			Usage: "Path of the Google dork queries file",
+		},
+		&cli.StringFlag{
+			Name:  "scope",
+			Usage: "File of domains, CIDRs and !exclusions that active probes and pivots must stay within",
+		},
+		&cli.StringFlag{
+			Name:  "audit-log",
+			Usage: "Append a JSON line for every HTTP request, DNS query, WHOIS lookup and command run to this file",
+		},
+		&cli.StringFlag{
+			Name:  "proxy",
+			Usage: "Route all traffic through an http://, https://, socks5:// or socks5h:// proxy",
+		},
+		&cli.BoolFlag{
+			Name:  "tor",
+			Usage: "Route all traffic through the local Tor daemon (" + transport.TorProxy + ")",
+		},
+		&cli.StringFlag{
+			Name:  "user-agent",
+			Usage: "User-Agent sent with every HTTP request",
+		},
+		&cli.BoolFlag{
+			Name:  "insecure",
+			Usage: "Skip TLS certificate verification",
+		},
+		&cli.Float64Flag{
+			Name:  "rate-limit",
+			Usage: "Default requests per second per host, 0 for unlimited (per-host limits come from the config)",
+		},
+		&cli.IntFlag{
+			Name:  "max-retries",
+			Usage: "Retries for requests answered with 429 or 5xx",
+		},
+		&cli.BoolFlag{
+			Name:  "no-cache",
+			Usage: "Neither read nor write the on-disk cache of crt.sh, WHOIS, search and Wayback answers",
+		},
+	}
+}
+
+// loadConfig reads the configuration, applies global flag overrides and
+// stores the result in the app metadata for the commands to use
+func loadConfig(c *cli.Context) error {
+	cfg, err := settings.Load(c.String("config"))
+	if err != nil {
+		return err
+	}
+	if c.IsSet("db") {
+		cfg.HistoryDB = c.String("db")
+	}
+	if c.IsSet("geolite-db") {
+		cfg.GeoLiteDB = c.String("geolite-db")
+	}
+	if c.IsSet("queries-file") {
+		cfg.QueriesFile = c.String("queries-file")
+	}
+	if c.IsSet("scope") {
+		cfg.ScopeFile = c.String("scope")
+	}
+	if c.IsSet("audit-log") {
+		cfg.AuditLog = c.String("audit-log")
+	}
+	if c.IsSet("proxy") {
+		cfg.Network.Proxy = c.String("proxy")
+	}
+	if c.Bool("tor") {
+		cfg.Network.Proxy = transport.TorProxy
+	}
+	if c.IsSet("user-agent") {
+		cfg.Network.UserAgent = c.String("user-agent")
+	}
+	if c.Bool("insecure") {
+		cfg.Network.Insecure = true
+	}
+	if c.IsSet("rate-limit") {
+		cfg.Network.RateLimit = c.Float64("rate-limit")
+	}
+	if c.IsSet("max-retries") {
+		cfg.Network.MaxRetries = c.Int("max-retries")
+	}
+	if err := transport.Configure(transport.Options{
+		Proxy:         cfg.Network.Proxy,
+		UserAgent:     cfg.Network.UserAgent,
+		Insecure:      cfg.Network.Insecure,
+		MinTLSVersion: cfg.Network.MinTLSVersion,
+		CACertFile:    cfg.Network.CACert,
+
+		RateLimit:      cfg.Network.RateLimit,
+		HostRateLimits: cfg.Network.HostRateLimits,
+		MaxRetries:     cfg.Network.MaxRetries,
+		RetryBackoff:   time.Duration(cfg.Network.RetryBackoff),
+		MaxRetryWait:   time.Duration(cfg.Network.MaxRetryWait),
+	}); err != nil {
+		return err
+	}
+
+	var s *scope.Scope
+	if cfg.ScopeFile != "" {
+		if s, err = scope.Load(cfg.ScopeFile); err != nil {
+			return err
+		}
+		// Names are resolved the way the dns module does, through any proxy
+		resolver := dns.NewDNSResolver()
+		resolver.Resolver = cfg.DNS.Resolver
+		s.Resolve = func(host string) ([]string, error) {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			return resolver.ResolveARecords(ctx, host)
+		}
+	}
+	scope.Enforce(s)
+
+	var auditLog *audit.Log
+	if cfg.AuditLog != "" {
+		if auditLog, err = audit.Open(cfg.AuditLog); err != nil {
+			return err
+		}
+	}
+	audit.Enable(auditLog)
+
+	if !c.Bool("no-cache") {
+		cache.Enable(cache.New(cfg.Cache.Dir, cfg.Cache.TTLs()))
+	}
+
+	geolite.Path = cfg.GeoLiteDB
+	if c.App.Metadata == nil {
+		c.App.Metadata = make(map[string]interface{})
+	}
+	c.App.Metadata[configKey] = cfg
+	return nil
+}
+
+// appConfig returns the configuration loaded for this run
+func appConfig(c *cli.Context) *settings.Config {
+	if cfg, ok := c.App.Metadata[configKey].(*settings.Config); ok {
+		return cfg
+	}
+	return settings.Default()
+}
+
+func configCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "config",
+		Usage: "Inspect the effective configuration",
+		Subcommands: []*cli.Command{
+			{
+				Name:  "show",
+				Usage: "Print the configuration after applying the file, environment and flags",
+				Action: func(c *cli.Context) error {
+					cfg := *appConfig(c)
+
+					// Fill in the built-in module deadlines so every tunable is visible
+					timeouts := make(map[string]settings.Duration)
+					for _, step := range pipeline.DefaultSteps(&cfg) {
+						timeouts[step.Name] = settings.Duration(step.Timeout)
+					}
+					cfg.Timeouts = timeouts
+					if cfg.MISP.Key != "" {
+						cfg.MISP.Key = "(set)"
+					}
+					// A proxy URL may carry a user name and password
+					if cfg.Network.Proxy != "" {
+						if u, err := url.Parse(cfg.Network.Proxy); err == nil {
+							cfg.Network.Proxy = u.Redacted()
+						} else {
+							cfg.Network.Proxy = "(set)"
+						}
+					}
+
+					if cfg.Source != "" {
+						fmt.Fprintf(c.App.Writer, "# Loaded from %s\n", cfg.Source)
+					} else {
+						fmt.Fprintln(c.App.Writer, "# No config file found, showing defaults")
+					}
+					return cfg.Write(c.App.Writer)
+				},
+			},
+		},
+	}
+}
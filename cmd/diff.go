@@ -0,0 +1,226 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/qepting91/gomain_analysis/internal/diff"
+	"github.com/qepting91/gomain_analysis/internal/model"
+	"github.com/qepting91/gomain_analysis/internal/pipeline"
+	"github.com/qepting91/gomain_analysis/internal/report"
+	"github.com/qepting91/gomain_analysis/internal/store"
+
+	"github.com/urfave/cli/v2"
+)
+
+func diffCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "diff",
+		Usage:     "Compare two scans of the same domain and report what changed",
+		ArgsUsage: "[old.json new.json]",
+		Description: "Compares two saved JSON reports, two stored scans (--domain with --from/--to), " +
+			"the latest stored scan against a fresh one (--domain with --live), " +
+			"or two fresh scans run one after the other (--domain with --live --twice).",
+		Flags: append([]cli.Flag{
+			&cli.StringFlag{
+				Name:  "domain",
+				Usage: "Compare stored scans of this domain",
+			},
+			&cli.Uint64Flag{
+				Name:  "from",
+				Usage: "Older scan ID (default: the scan before --to)",
+			},
+			&cli.Uint64Flag{
+				Name:  "to",
+				Usage: "Newer scan ID (default: most recent)",
+			},
+			&cli.BoolFlag{
+				Name:  "live",
+				Usage: "Run a fresh scan and compare it with the most recent stored one",
+			},
+			&cli.BoolFlag{
+				Name:  "twice",
+				Usage: "Compare two fresh scans instead of a fresh scan and the most recent stored one (implies --live)",
+			},
+			&cli.DurationFlag{
+				Name:  "interval",
+				Usage: "With --twice, wait this long between the two scans",
+			},
+			&cli.BoolFlag{
+				Name:  "no-store",
+				Usage: "With --live, do not save the fresh scans",
+			},
+			&cli.StringFlag{
+				Name:  "format",
+				Usage: "Output format: text or json",
+				Value: "text",
+			},
+		}, selectionFlags()...),
+		Action: func(c *cli.Context) error {
+			var older, newer *model.DomainReport
+			var err error
+			switch {
+			case c.NArg() == 2:
+				if older, err = report.ReadJSON(c.Args().Get(0)); err != nil {
+					return err
+				}
+				if newer, err = report.ReadJSON(c.Args().Get(1)); err != nil {
+					return err
+				}
+			case c.String("domain") != "" && (c.Bool("live") || c.Bool("twice")):
+				older, newer, err = liveScans(c)
+			case c.String("domain") != "":
+				older, newer, err = storedScans(c)
+			default:
+				return fmt.Errorf("pass two JSON reports, or --domain to compare stored scans")
+			}
+			if err != nil {
+				return err
+			}
+
+			d, err := diff.Compare(older, newer)
+			if err != nil {
+				return err
+			}
+			switch c.String("format") {
+			case "json":
+				return writeJSON(c.App.Writer, d)
+			case "text":
+				return diff.WriteText(c.App.Writer, d)
+			default:
+				return fmt.Errorf("unsupported diff format %q (supported: text, json)", c.String("format"))
+			}
+		},
+	}
+}
+
+// storedScans loads the two history entries selected by --from and --to
+func storedScans(c *cli.Context) (*model.DomainReport, *model.DomainReport, error) {
+	s, err := openStore(c)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer s.Close()
+
+	domain, err := domainArg(c)
+	if err != nil {
+		return nil, nil, err
+	}
+	newer, toID, err := s.Get(domain, c.Uint64("to"))
+	if err != nil {
+		return nil, nil, scanLookupError(domain, err)
+	}
+
+	fromID := c.Uint64("from")
+	if fromID == 0 {
+		entries, err := s.List(domain)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, e := range entries {
+			if e.ID < toID {
+				fromID = e.ID
+			}
+		}
+		if fromID == 0 {
+			return nil, nil, fmt.Errorf("only one stored scan of %s, nothing to compare against", domain)
+		}
+	}
+	older, _, err := s.Get(domain, fromID)
+	if err != nil {
+		return nil, nil, scanLookupError(domain, err)
+	}
+	return older, newer, nil
+}
+
+// liveScans runs a fresh scan and pairs it with the most recent stored one,
+// or with --twice runs two fresh scans and pairs them. The store is closed
+// while a scan runs so other commands can use it.
+func liveScans(c *cli.Context) (*model.DomainReport, *model.DomainReport, error) {
+	domain, err := domainArg(c)
+	if err != nil {
+		return nil, nil, err
+	}
+	var older *model.DomainReport
+	if !c.Bool("twice") {
+		if older, err = latestScan(c, domain); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	sel, err := selectSteps(c)
+	if err != nil {
+		return nil, nil, err
+	}
+	ctx, stop := signal.NotifyContext(c.Context, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if older == nil {
+		if older, err = freshScan(ctx, c, domain, sel); err != nil {
+			return nil, nil, err
+		}
+		if interval := c.Duration("interval"); interval > 0 {
+			fmt.Fprintf(c.App.ErrWriter, "Waiting %s before the second scan\n", interval)
+			select {
+			case <-time.After(interval):
+			case <-ctx.Done():
+				return nil, nil, ctx.Err()
+			}
+		}
+	}
+	newer, err := freshScan(ctx, c, domain, sel)
+	if err != nil {
+		return nil, nil, err
+	}
+	return older, newer, nil
+}
+
+// freshScan runs one scan within --timeout and saves it unless --no-store
+func freshScan(ctx context.Context, c *cli.Context, domain string, sel *pipeline.Selection) (*model.DomainReport, error) {
+	if timeout := c.Duration("timeout"); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	r, err := pipeline.Analyze(ctx, domain, sel, c.App.ErrWriter)
+	if err != nil {
+		return nil, err
+	}
+	if !c.Bool("no-store") {
+		s, err := openStore(c)
+		if err != nil {
+			return nil, err
+		}
+		defer s.Close()
+		if _, err := s.Save(r); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// latestScan reads the most recent stored scan of domain
+func latestScan(c *cli.Context, domain string) (*model.DomainReport, error) {
+	s, err := openStore(c)
+	if err != nil {
+		return nil, err
+	}
+	defer s.Close()
+	r, _, err := s.Get(domain, 0)
+	if err != nil {
+		return nil, scanLookupError(domain, err)
+	}
+	return r, nil
+}
+
+func scanLookupError(domain string, err error) error {
+	if errors.Is(err, store.ErrNotFound) {
+		return fmt.Errorf("no stored scan for %s (see the history command)", domain)
+	}
+	return err
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/qepting91/gomain_analysis/internal/report"
+	"github.com/qepting91/gomain_analysis/internal/store"
+	"github.com/qepting91/gomain_analysis/internal/target"
+
+	"github.com/urfave/cli/v2"
+)
+
+// openStore opens the configured history database
+func openStore(c *cli.Context) (*store.Store, error) {
+	return store.Open(appConfig(c).HistoryDB)
+}
+
+// domainArg returns the normalized --domain flag, or "" when it is not set
+func domainArg(c *cli.Context) (string, error) {
+	if c.String("domain") == "" {
+		return "", nil
+	}
+	return target.Normalize(c.String("domain"))
+}
+
+func historyCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "history",
+		Usage: "List stored scans, for one domain or for all domains",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "domain",
+				Usage: "Domain whose scans to list (default: list all domains)",
+			},
+			&cli.BoolFlag{
+				Name:  "json",
+				Usage: "Print the list as JSON",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			s, err := openStore(c)
+			if err != nil {
+				return err
+			}
+			defer s.Close()
+
+			out := c.App.Writer
+			domain, err := domainArg(c)
+			if err != nil {
+				return err
+			}
+			if domain == "" {
+				domains, err := s.Domains()
+				if err != nil {
+					return err
+				}
+				if c.Bool("json") {
+					return writeJSON(out, domains)
+				}
+				tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+				fmt.Fprintln(tw, "DOMAIN\tSCANS\tLAST SCAN")
+				for _, d := range domains {
+					fmt.Fprintf(tw, "%s\t%d\t%s\n", d.Domain, d.Scans, d.LastScan.Format(time.RFC3339))
+				}
+				return tw.Flush()
+			}
+
+			entries, err := s.List(domain)
+			if err != nil {
+				return err
+			}
+			if c.Bool("json") {
+				return writeJSON(out, entries)
+			}
+			if len(entries) == 0 {
+				fmt.Fprintf(out, "No stored scans for %s\n", domain)
+				return nil
+			}
+			tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(tw, "ID\tSCANNED AT\tPROFILE\tMODULES OK\tINTERRUPTED")
+			for _, e := range entries {
+				fmt.Fprintf(tw, "%d\t%s\t%s\t%d/%d\t%t\n",
+					e.ID, e.ScannedAt.Format(time.RFC3339), e.Profile, e.Completed, e.Total, e.Interrupted)
+			}
+			return tw.Flush()
+		},
+	}
+}
+
+func showCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "show",
+		Usage: "Re-render a stored scan without touching the network",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:     "domain",
+				Usage:    "Domain of the stored scan",
+				Required: true,
+			},
+			&cli.Uint64Flag{
+				Name:  "id",
+				Usage: "Scan ID from the history command (default: most recent)",
+			},
+			&cli.StringFlag{
+				Name:  "format",
+				Usage: "Output format: " + strings.Join(report.Formats(), ", "),
+				Value: report.FormatText,
+			},
+			&cli.StringFlag{
+				Name:  "output",
+				Usage: "Output file path, or - for stdout",
+				Value: "-",
+			},
+			pushMISPFlag(),
+		},
+		Action: func(c *cli.Context) error {
+			s, err := openStore(c)
+			if err != nil {
+				return err
+			}
+			defer s.Close()
+
+			domain, err := domainArg(c)
+			if err != nil {
+				return err
+			}
+			mispPush, err := mispClient(c)
+			if err != nil {
+				return err
+			}
+			r, _, err := s.Get(domain, c.Uint64("id"))
+			if err != nil {
+				return scanLookupError(domain, err)
+			}
+			if err := report.WriteFile(c.String("output"), c.String("format"), r); err != nil {
+				return err
+			}
+			return pushMISP(c.Context, mispPush, r, c.App.ErrWriter)
+		},
+	}
+}
+
+// writeJSON prints v as indented JSON
+func writeJSON(w io.Writer, v interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
@@ -0,0 +1,460 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/qepting91/gomain_analysis/internal/audit"
+	"github.com/qepting91/gomain_analysis/internal/crt"
+	"github.com/qepting91/gomain_analysis/internal/geolocation"
+	"github.com/qepting91/gomain_analysis/internal/model"
+	"github.com/qepting91/gomain_analysis/internal/modules"
+	"github.com/qepting91/gomain_analysis/internal/target"
+
+	"github.com/urfave/cli/v2"
+)
+
+// lookupFlags are shared by the commands that run a single module
+func lookupFlags(extra ...cli.Flag) []cli.Flag {
+	return append([]cli.Flag{
+		&cli.StringFlag{
+			Name:  "domain",
+			Usage: "Domain or URL to look up (or pass it as the first argument)",
+		},
+		&cli.BoolFlag{
+			Name:  "json",
+			Usage: "Print the result as JSON",
+		},
+		&cli.DurationFlag{
+			Name:  "timeout",
+			Usage: "Deadline for the lookup (default: the module's timeout)",
+		},
+	}, extra...)
+}
+
+// lookupInput returns the target given as --domain or as the first argument
+func lookupInput(c *cli.Context) (string, error) {
+	input := c.String("domain")
+	if input == "" {
+		input = c.Args().First()
+	}
+	if input == "" {
+		return "", fmt.Errorf("a domain is required, e.g. %s %s example.com", c.App.Name, c.Command.Name)
+	}
+	if c.NArg() > 1 {
+		return "", fmt.Errorf("unexpected arguments %v; options must come before the domain", c.Args().Tail())
+	}
+	return input, nil
+}
+
+// lookupContext is cancelled on Ctrl-C so a slow lookup can be abandoned
+func lookupContext(c *cli.Context) (context.Context, context.CancelFunc) {
+	return signal.NotifyContext(c.Context, os.Interrupt, syscall.SIGTERM)
+}
+
+// runModule runs one module on its own and applies its result to r. Any
+// dependencies must already be in r.
+func runModule(ctx context.Context, c *cli.Context, name string, t model.Target, r *model.DomainReport) (modules.Result, error) {
+	cfg := appConfig(c)
+	m, err := modules.Get(name, cfg)
+	if err != nil {
+		return nil, err
+	}
+	timeout := cfg.Timeout(name, m.Timeout())
+	if c.IsSet("timeout") {
+		timeout = c.Duration("timeout")
+	}
+	ctx, cancel := context.WithTimeout(audit.WithModule(ctx, name), timeout)
+	defer cancel()
+
+	res, err := m.Run(ctx, modules.Target{Target: t, Report: r})
+	if res != nil {
+		res.Apply(r)
+	}
+	return res, err
+}
+
+// lookupCommand builds a command that runs the named module against the
+// target argument and prints its result with print unless --json is given
+func lookupCommand(name, usage string, print func(w io.Writer, r *model.DomainReport)) *cli.Command {
+	return &cli.Command{
+		Name:      name,
+		Usage:     usage,
+		ArgsUsage: "<domain>",
+		Flags:     lookupFlags(),
+		Action: func(c *cli.Context) error {
+			input, err := lookupInput(c)
+			if err != nil {
+				return err
+			}
+			t, err := target.Parse(input)
+			if err != nil {
+				return err
+			}
+			ctx, stop := lookupContext(c)
+			defer stop()
+
+			r := model.NewDomainReport(t.Host)
+			r.Target = t
+			res, err := runModule(ctx, c, name, t, r)
+			if res == nil {
+				return err
+			}
+			if c.Bool("json") {
+				if jsonErr := writeJSON(c.App.Writer, res); jsonErr != nil {
+					return jsonErr
+				}
+			} else {
+				print(c.App.Writer, r)
+			}
+			return err
+		},
+	}
+}
+
+// lookupCommands exposes the modules that make sense on their own as
+// commands of their own
+func lookupCommands() []*cli.Command {
+	return []*cli.Command{
+		crtCommand(),
+		dnsCommand(),
+		lookupCommand("whois", "Look up the WHOIS record of a domain", printWHOIS),
+		geoCommand(),
+		lookupCommand("wayback", "List the Wayback Machine snapshots of a domain", printWayback),
+		lookupCommand("dork", "Build the Google dork searches for a domain", printDorks),
+		lookupCommand("web", "Fetch and parse a website", printWeb),
+	}
+}
+
+func crtCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "crt",
+		Usage:     "Search certificate transparency logs on crt.sh",
+		ArgsUsage: "<domain>",
+		Flags: lookupFlags(
+			&cli.BoolFlag{
+				Name:  "only-domains",
+				Usage: "Print only the distinct names found in the logs",
+			},
+			&cli.BoolFlag{
+				Name:  "download",
+				Usage: "Download and parse every logged certificate, like a full scan does",
+			},
+			&cli.IntFlag{
+				Name:  "cert",
+				Usage: "Download and parse the certificate with this crt.sh ID instead of searching",
+			},
+		),
+		Action: func(c *cli.Context) error {
+			ctx, stop := lookupContext(c)
+			defer stop()
+			ctx = audit.WithModule(ctx, "crt")
+			out := c.App.Writer
+
+			if id := c.Int("cert"); id != 0 {
+				pemData, err := crt.DownloadPemFile(ctx, id)
+				if err != nil {
+					return fmt.Errorf("failed to download certificate %d: %v", id, err)
+				}
+				cert, err := crt.ParseCertificate(pemData)
+				if err != nil {
+					return err
+				}
+				if c.Bool("json") {
+					return writeJSON(out, crt.NewCertificate(model.CTLog{MinCertID: id}, cert))
+				}
+				crt.PrintCertDetails(out, cert)
+				return nil
+			}
+
+			input, err := lookupInput(c)
+			if err != nil {
+				return err
+			}
+			t, err := target.Parse(input)
+			if err != nil {
+				return err
+			}
+			r := model.NewDomainReport(t.Host)
+			r.Target = t
+
+			if c.Bool("download") {
+				res, err := runModule(ctx, c, "crt", t, r)
+				if res == nil {
+					return err
+				}
+				if c.Bool("json") {
+					if jsonErr := writeJSON(out, res); jsonErr != nil {
+						return jsonErr
+					}
+				} else {
+					printCertificates(out, r.Certificates)
+				}
+				return err
+			}
+
+			// A plain search skips the PEM downloads, which take minutes for
+			// popular domains
+			if c.IsSet("timeout") {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, c.Duration("timeout"))
+				defer cancel()
+			}
+			logs, err := crt.QueryByDomain(ctx, modules.Target{Target: t}.Name(modules.ScopeApex))
+			if err != nil {
+				return err
+			}
+			if c.Bool("only-domains") {
+				names := ctNames(logs)
+				if c.Bool("json") {
+					return writeJSON(out, names)
+				}
+				for _, name := range names {
+					fmt.Fprintln(out, name)
+				}
+				return nil
+			}
+			if c.Bool("json") {
+				return writeJSON(out, logs)
+			}
+			tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(tw, "CERT ID\tVALID FROM\tVALID TO\tISSUER\tNAMES")
+			for _, entry := range logs {
+				fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\n", entry.MinCertID, entry.NotBefore, entry.NotAfter,
+					entry.IssuerName, strings.ReplaceAll(entry.NameValue, "\n", ", "))
+			}
+			return tw.Flush()
+		},
+	}
+}
+
+// ctNames returns the distinct names of the log entries in sorted order.
+// crt.sh puts one name per line in name_value.
+func ctNames(logs []model.CTLog) []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, entry := range logs {
+		for _, name := range strings.Split(entry.NameValue, "\n") {
+			name = strings.ToLower(strings.TrimSpace(name))
+			if name != "" && !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func dnsCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "dns",
+		Usage:     "Resolve the A and MX records of a domain",
+		ArgsUsage: "<domain>",
+		Flags: lookupFlags(&cli.BoolFlag{
+			Name:  "reverse",
+			Usage: "Also look up the names pointing back at each address",
+		}),
+		Action: func(c *cli.Context) error {
+			input, err := lookupInput(c)
+			if err != nil {
+				return err
+			}
+			t, err := target.Parse(input)
+			if err != nil {
+				return err
+			}
+			ctx, stop := lookupContext(c)
+			defer stop()
+
+			r := model.NewDomainReport(t.Host)
+			r.Target = t
+			if _, err := runModule(ctx, c, "dns", t, r); err != nil {
+				return err
+			}
+			if c.Bool("reverse") {
+				if _, err := runModule(ctx, c, "reverse", t, r); err != nil {
+					log.Printf("Error performing reverse DNS lookup: %v", err)
+				}
+			}
+
+			if c.Bool("json") {
+				return writeJSON(c.App.Writer, r.DNS)
+			}
+			out := c.App.Writer
+			for _, record := range r.DNS.ARecords {
+				fmt.Fprintf(out, "A\t%s", record)
+				if names, ok := r.DNS.ReverseDNS[record]; ok {
+					fmt.Fprintf(out, "\t(%s)", strings.Join(names, ", "))
+				}
+				fmt.Fprintln(out)
+			}
+			for _, mx := range r.DNS.MXRecords {
+				fmt.Fprintf(out, "MX\t%s\n", mx)
+			}
+			if d := r.DNS.DMARC; d != nil {
+				if d.Record != "" {
+					fmt.Fprintf(out, "DMARC\t%s\t(_dmarc.%s)\n", d.Record, d.Domain)
+				} else {
+					fmt.Fprintln(out, "DMARC\tnone published")
+				}
+			}
+			return nil
+		},
+	}
+}
+
+func geoCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "geo",
+		Usage:     "Locate IP addresses, or the addresses a domain resolves to, in the GeoLite2 database",
+		ArgsUsage: "<ip or domain>...",
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:  "json",
+				Usage: "Print the result as JSON",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			if c.NArg() == 0 {
+				return fmt.Errorf("at least one IP address or domain is required, e.g. %s geo 8.8.8.8", c.App.Name)
+			}
+			ctx, stop := lookupContext(c)
+			defer stop()
+
+			// Addresses are located directly; domains are resolved first
+			var ips []string
+			for _, arg := range c.Args().Slice() {
+				t, err := target.Parse(arg)
+				if err != nil {
+					return err
+				}
+				if t.IsIP {
+					ips = append(ips, t.Host)
+					continue
+				}
+				r := model.NewDomainReport(t.Host)
+				r.Target = t
+				if _, err := runModule(ctx, c, "dns", t, r); err != nil {
+					return err
+				}
+				ips = append(ips, r.DNS.ARecords...)
+			}
+
+			r := &model.DomainReport{}
+			r.DNS.ARecords = ips
+			if _, err := runModule(ctx, c, "geo", model.Target{}, r); err != nil {
+				return err
+			}
+			if c.Bool("json") {
+				return writeJSON(c.App.Writer, r.Geo)
+			}
+			for i, loc := range r.Geo {
+				if i > 0 {
+					fmt.Fprintln(c.App.Writer)
+				}
+				fmt.Fprintf(c.App.Writer, "IP: %s\n%s", loc.IP, geolocation.FormatGeoLocation(loc))
+			}
+			return nil
+		},
+	}
+}
+
+func printCertificates(w io.Writer, certs []model.Certificate) {
+	for i, cert := range certs {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintf(w, "Cert ID: %d\n", cert.ID)
+		fmt.Fprintf(w, "  Subject: %s\n", cert.Subject)
+		fmt.Fprintf(w, "  Issuer: %s\n", cert.Issuer)
+		fmt.Fprintf(w, "  Valid From: %s\n", cert.NotBefore.Format(time.RFC3339))
+		fmt.Fprintf(w, "  Valid To: %s\n", cert.NotAfter.Format(time.RFC3339))
+		fmt.Fprintf(w, "  SHA-256: %s\n", cert.FingerprintSHA256)
+		if len(cert.DNSNames) > 0 {
+			fmt.Fprintf(w, "  DNS Names: %s\n", strings.Join(cert.DNSNames, ", "))
+		}
+	}
+}
+
+func printWHOIS(w io.Writer, r *model.DomainReport) {
+	info := r.WHOIS
+	if info.Registrar != "" {
+		fmt.Fprintf(w, "Registrar: %s\n", info.Registrar)
+	}
+	for _, date := range []struct {
+		label string
+		t     *time.Time
+	}{{"Created", info.CreatedAt}, {"Updated", info.UpdatedAt}, {"Expires", info.ExpiresAt}} {
+		if date.t != nil {
+			fmt.Fprintf(w, "%s: %s\n", date.label, date.t.Format("2006-01-02"))
+		}
+	}
+	if len(info.NameServers) > 0 {
+		fmt.Fprintf(w, "Name Servers: %s\n", strings.Join(info.NameServers, ", "))
+	}
+	if len(info.Status) > 0 {
+		fmt.Fprintf(w, "Status: %s\n", strings.Join(info.Status, ", "))
+	}
+	fmt.Fprintf(w, "\n%s\n", strings.TrimSpace(info.Raw))
+}
+
+func printWayback(w io.Writer, r *model.DomainReport) {
+	if len(r.Wayback) == 0 {
+		fmt.Fprintln(w, "No Wayback Machine snapshots found.")
+	}
+	for _, snapshot := range r.Wayback {
+		fmt.Fprintf(w, "%s  %s  %s\n", snapshot.Timestamp.Format("2006-01-02 15:04:05"), snapshot.Status, snapshot.URL)
+	}
+}
+
+func printDorks(w io.Writer, r *model.DomainReport) {
+	for _, result := range r.Dorks {
+		if result.Error != "" {
+			fmt.Fprintf(w, "%s\n  Error: %s\n", result.Query, result.Error)
+		} else {
+			fmt.Fprintf(w, "%s\n  %s\n", result.Query, result.URL)
+		}
+	}
+}
+
+func printWeb(w io.Writer, r *model.DomainReport) {
+	fmt.Fprintf(w, "URL: %s\n", r.Web.URL)
+	content := r.Web.Content
+	if content == nil {
+		fmt.Fprintln(w, "No website content available.")
+		return
+	}
+	fmt.Fprintf(w, "Title: %s\n", content.Title)
+	for _, field := range []struct {
+		label  string
+		values []string
+	}{
+		{"Technologies", content.Technologies},
+		{"Emails", content.Emails},
+		{"Phone Numbers", content.PhoneNumbers},
+		{"Forms", content.Forms},
+	} {
+		if len(field.values) > 0 {
+			fmt.Fprintf(w, "%s: %s\n", field.label, strings.Join(field.values, ", "))
+		}
+	}
+	platforms := make([]string, 0, len(content.SocialMedia))
+	for platform := range content.SocialMedia {
+		platforms = append(platforms, platform)
+	}
+	sort.Strings(platforms)
+	for _, platform := range platforms {
+		fmt.Fprintf(w, "%s: %s\n", platform, strings.Join(content.SocialMedia[platform], ", "))
+	}
+	fmt.Fprintf(w, "Links: %d internal, %d external\n", len(content.InternalLinks), len(content.ExternalLinks))
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/qepting91/gomain_analysis/internal/misp"
+	"github.com/qepting91/gomain_analysis/internal/model"
+
+	"github.com/urfave/cli/v2"
+)
+
+// pushMISPFlag sends the finished report to the configured MISP instance
+func pushMISPFlag() cli.Flag {
+	return &cli.BoolFlag{
+		Name:  "push-misp",
+		Usage: "Also create a MISP event on the instance set by misp.url and $GOMAIN_MISP_KEY",
+	}
+}
+
+// mispClient returns the client for --push-misp, or nil when it is not set.
+// Commands call it before doing any work so a missing key fails fast.
+func mispClient(c *cli.Context) (*misp.Client, error) {
+	if !c.Bool("push-misp") {
+		return nil, nil
+	}
+	cfg := appConfig(c).MISP
+	if cfg.URL == "" || cfg.Key == "" {
+		return nil, fmt.Errorf("--push-misp needs misp.url and misp.key in the config, or $GOMAIN_MISP_URL and $GOMAIN_MISP_KEY")
+	}
+	return &misp.Client{URL: cfg.URL, Key: cfg.Key}, nil
+}
+
+// pushMISP creates a MISP event for r; a nil client does nothing
+func pushMISP(ctx context.Context, client *misp.Client, r *model.DomainReport, progress io.Writer) error {
+	if client == nil {
+		return nil
+	}
+	id, err := client.Push(ctx, misp.Build(r))
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(progress, "Created MISP event %s on %s\n", id, client.URL)
+	return nil
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/qepting91/gomain_analysis/internal/modules"
+
+	"github.com/urfave/cli/v2"
+)
+
+func modulesCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "modules",
+		Usage: "List the available analysis modules",
+		Action: func(c *cli.Context) error {
+			tw := tabwriter.NewWriter(c.App.Writer, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(tw, "MODULE\tTYPE\tSCOPE\tDEPENDS ON\tTIMEOUT\tDESCRIPTION")
+			for _, m := range modules.New(appConfig(c)) {
+				kind := "active"
+				if m.Passive() {
+					kind = "passive"
+				}
+				deps := strings.Join(m.Dependencies(), ", ")
+				if deps == "" {
+					deps = "-"
+				}
+				timeout := appConfig(c).Timeout(m.Name(), m.Timeout())
+				fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", m.Name(), kind, m.Scope(), deps, timeout, m.Description())
+			}
+			return tw.Flush()
+		},
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/qepting91/gomain_analysis/internal/alert"
+	"github.com/qepting91/gomain_analysis/internal/batch"
+	"github.com/qepting91/gomain_analysis/internal/monitor"
+	"github.com/qepting91/gomain_analysis/internal/store"
+
+	"github.com/urfave/cli/v2"
+)
+
+func monitorCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "monitor",
+		Usage: "Re-scan a watch list on a schedule and alert when something changed",
+		Flags: append([]cli.Flag{
+			&cli.StringFlag{
+				Name:     "watchlist",
+				Usage:    "File with one domain per line",
+				Required: true,
+			},
+			&cli.DurationFlag{
+				Name:  "interval",
+				Usage: "Time between checks",
+				Value: 6 * time.Hour,
+			},
+			&cli.BoolFlag{
+				Name:  "once",
+				Usage: "Check every domain once and exit (for cron)",
+			},
+			&cli.StringSliceFlag{
+				Name:  "alert",
+				Usage: "Alert destination: stdout, file:<path> or webhook:<url> (repeatable)",
+				Value: cli.NewStringSlice("stdout"),
+			},
+		}, selectionFlags()...),
+		Action: func(c *cli.Context) error {
+			sel, err := selectSteps(c)
+			if err != nil {
+				return err
+			}
+
+			var notifiers []alert.Notifier
+			for _, spec := range c.StringSlice("alert") {
+				n, err := alert.Parse(spec, c.App.Writer)
+				if err != nil {
+					return err
+				}
+				notifiers = append(notifiers, n)
+			}
+
+			watchlist := c.String("watchlist")
+			loadDomains := func() ([]string, error) {
+				file, err := os.Open(watchlist)
+				if err != nil {
+					return nil, fmt.Errorf("failed to open watch list: %v", err)
+				}
+				defer file.Close()
+				return batch.ReadDomains(file)
+			}
+			domains, err := loadDomains()
+			if err != nil {
+				return err
+			}
+
+			// Fail early on an unusable history path; the store is then
+			// opened only around each read and write
+			s, err := openStore(c)
+			if err != nil {
+				return err
+			}
+			s.Close()
+
+			ctx, stop := signal.NotifyContext(c.Context, os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			m := &monitor.Monitor{
+				Selection: sel,
+				OpenStore: func() (*store.Store, error) { return openStore(c) },
+				Notifiers: notifiers,
+				Timeout:   c.Duration("timeout"),
+				Progress:  c.App.ErrWriter,
+			}
+			fmt.Fprintf(c.App.ErrWriter, "Monitoring %d domains (profile: %s)\n", len(domains), sel.Profile)
+			if c.Bool("once") {
+				m.RunOnce(ctx, domains)
+				return nil
+			}
+			return m.Run(ctx, loadDomains, c.Duration("interval"))
+		},
+	}
+}
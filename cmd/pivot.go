@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"io"
+
+	"github.com/qepting91/gomain_analysis/internal/model"
+	"github.com/qepting91/gomain_analysis/internal/pipeline"
+	"github.com/qepting91/gomain_analysis/internal/pivot"
+	"github.com/qepting91/gomain_analysis/internal/scope"
+
+	"github.com/urfave/cli/v2"
+)
+
+// pivotFlags control recursive analysis of what a scan discovers
+func pivotFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.IntFlag{
+			Name:  "depth",
+			Usage: "Rounds of pivoting to in-scope hosts found by the scan (0 disables)",
+		},
+		&cli.StringSliceFlag{
+			Name:  "pivot-scope",
+			Usage: "Domains whose hosts may be pivoted to (default: the domains of the scope file, or the target's registrable domain)",
+		},
+		&cli.BoolFlag{
+			Name:  "pivot-ips",
+			Usage: "Also pivot to the IP addresses of in-scope hosts",
+		},
+		&cli.StringSliceFlag{
+			Name:  "pivot-modules",
+			Usage: "Modules run against each pivot (default: dns, web and geo, limited to those the scan itself ran)",
+		},
+		&cli.IntFlag{
+			Name:  "max-pivots",
+			Usage: "Maximum number of pivots across all rounds",
+			Value: 25,
+		},
+	}
+}
+
+// pivotSelection picks the modules run against each pivot, or returns nil
+// when --depth is not set. It is called before the scan so bad module names
+// fail fast.
+func pivotSelection(c *cli.Context, sel *pipeline.Selection) (*pipeline.Selection, error) {
+	if c.Int("depth") <= 0 {
+		return nil, nil
+	}
+	names := c.StringSlice("pivot-modules")
+	if len(names) == 0 {
+		// Pivots never run anything the main scan left out, e.g. active
+		// modules in a passive scan
+		ran := make(map[string]bool)
+		for _, step := range sel.Steps {
+			ran[step.Name] = true
+		}
+		for _, name := range pivot.DefaultModules {
+			if ran[name] {
+				names = append(names, name)
+			}
+		}
+		if len(names) == 0 {
+			names = pipeline.StepNames(sel.Steps)
+		}
+	}
+	return pipeline.Select(pipeline.DefaultSteps(appConfig(c)), "", names, nil)
+}
+
+// runPivots analyzes the in-scope discoveries of r with the modules chosen
+// by pivotSelection; a nil selection does nothing
+func runPivots(ctx context.Context, c *cli.Context, r *model.DomainReport, sel *pipeline.Selection, progress io.Writer) {
+	if sel == nil {
+		return
+	}
+	allowed := pivot.Scope{Domains: c.StringSlice("pivot-scope"), IPs: c.Bool("pivot-ips")}
+	if len(allowed.Domains) == 0 {
+		if s := scope.Active(); s != nil && len(s.Domains) > 0 {
+			allowed.Domains = s.Domains
+		} else if r.Target.Apex != "" {
+			allowed.Domains = []string{r.Target.Apex}
+		} else if !r.Target.IsIP {
+			allowed.Domains = []string{r.Domain}
+		}
+	}
+
+	pivot.Run(ctx, r, pivot.Options{
+		Depth:      c.Int("depth"),
+		Scope:      allowed,
+		MaxTargets: c.Int("max-pivots"),
+		Workers:    4,
+	}, func(ctx context.Context, target string) (*model.DomainReport, error) {
+		return pipeline.Analyze(ctx, target, sel, io.Discard)
+	}, progress)
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/qepting91/gomain_analysis/internal/replay"
+
+	"github.com/urfave/cli/v2"
+)
+
+// replayFlags are the global flags that capture or reproduce a run's traffic
+func replayFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:  "record",
+			Usage: "Save every HTTP, DNS, WHOIS and external command answer to this directory",
+		},
+		&cli.StringFlag{
+			Name:  "replay",
+			Usage: "Answer all lookups from a directory written by --record, without network access",
+		},
+	}
+}
+
+// startReplay activates recording or replay when requested
+func startReplay(c *cli.Context) error {
+	record, replayDir := c.String("record"), c.String("replay")
+	switch {
+	case record != "" && replayDir != "":
+		return fmt.Errorf("--record and --replay cannot be used together")
+	case record != "":
+		_, err := replay.Start(replay.Record, record)
+		return err
+	case replayDir != "":
+		_, err := replay.Start(replay.Replay, replayDir)
+		return err
+	}
+	return nil
+}
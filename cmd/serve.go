@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/qepting91/gomain_analysis/internal/pipeline"
+	"github.com/qepting91/gomain_analysis/internal/server"
+	"github.com/qepting91/gomain_analysis/internal/store"
+
+	"github.com/urfave/cli/v2"
+)
+
+func serveCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "serve",
+		Usage: "Expose the analysis as a REST API",
+		Description: "Endpoints:\n" +
+			"   POST   /api/scans                  submit {\"domain\": ..., \"profile\": ..., \"modules\": [...], \"skip\": [...]}\n" +
+			"   GET    /api/scans                  list jobs\n" +
+			"   GET    /api/scans/{id}             job status\n" +
+			"   DELETE /api/scans/{id}             cancel a job\n" +
+			"   GET    /api/scans/{id}/result      report as JSON\n" +
+			"   GET    /api/scans/{id}/report.pdf  report as PDF\n" +
+			"   GET    /api/modules                available modules and profiles",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "listen",
+				Usage: "Address to listen on",
+				Value: "127.0.0.1:8080",
+			},
+			&cli.IntFlag{
+				Name:  "workers",
+				Usage: "Number of scans run concurrently; further jobs are queued",
+				Value: 2,
+			},
+			&cli.DurationFlag{
+				Name:  "timeout",
+				Usage: "Deadline for each scan job (e.g. 10m)",
+			},
+			&cli.BoolFlag{
+				Name:  "no-store",
+				Usage: "Do not save results in the scan history database",
+			},
+			&cli.DurationFlag{
+				Name:  "job-ttl",
+				Usage: "How long finished jobs stay listed by the API; their reports stay in the history",
+				Value: server.DefaultJobTTL,
+			},
+		},
+		Action: func(c *cli.Context) error {
+			ctx, stop := signal.NotifyContext(c.Context, os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			cfg := appConfig(c)
+			srv := server.New(ctx, pipeline.DefaultSteps(cfg), c.Int("workers"))
+			srv.Timeout = c.Duration("timeout")
+			srv.Profile = cfg.Profile
+			srv.JobTTL = c.Duration("job-ttl")
+			if !c.Bool("no-store") {
+				// Fail early on an unusable history path; each job then
+				// opens the store only to save its report
+				s, err := openStore(c)
+				if err != nil {
+					return err
+				}
+				s.Close()
+				srv.OpenStore = func() (*store.Store, error) { return openStore(c) }
+			}
+
+			httpServer := &http.Server{
+				Addr:              c.String("listen"),
+				Handler:           srv.Handler(),
+				ReadHeaderTimeout: 10 * time.Second,
+			}
+			errCh := make(chan error, 1)
+			go func() {
+				errCh <- httpServer.ListenAndServe()
+			}()
+			log.Printf("API listening on http://%s", c.String("listen"))
+
+			select {
+			case err := <-errCh:
+				if !errors.Is(err, http.ErrServerClosed) {
+					return fmt.Errorf("API server failed: %v", err)
+				}
+				return nil
+			case <-ctx.Done():
+			}
+
+			log.Printf("Shutting down API server")
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			err := httpServer.Shutdown(shutdownCtx)
+			// Jobs were cancelled with ctx; let them save their reports
+			if waitErr := srv.Wait(shutdownCtx); waitErr != nil {
+				log.Printf("Error waiting for scan jobs: %v", waitErr)
+			}
+			return err
+		},
+	}
+}
@@ -7,40 +7,24 @@ toolchain go1.23.3
 require (
 	github.com/PuerkitoBio/goquery v1.10.0
 	github.com/domainr/whois v0.1.0
+	github.com/go-pdf/fpdf v0.9.0
 	github.com/oschwald/geoip2-golang v1.11.0
 	github.com/seekr-osint/wayback-machine-golang v1.1.2
 	github.com/urfave/cli/v2 v2.27.4
+	go.etcd.io/bbolt v1.3.11
+	golang.org/x/net v0.31.0
+	golang.org/x/time v0.8.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
-	github.com/R4yGM/dorkscout v0.0.0-20210818101352-dd87daf1c10a // indirect
 	github.com/andybalholm/cascadia v1.3.2 // indirect
-	github.com/antchfx/htmlquery v1.2.3 // indirect
-	github.com/antchfx/xmlquery v1.2.4 // indirect
-	github.com/antchfx/xpath v1.1.8 // indirect
-	github.com/buger/jsonparser v1.1.1 // indirect
 	github.com/cpuguy83/go-md2man/v2 v2.0.4 // indirect
-	github.com/famasoon/crtsh v0.0.0-20220819163426-df40d0a6f9f5 // indirect
-	github.com/go-pdf/fpdf v0.9.0 // indirect
-	github.com/gobwas/glob v0.2.3 // indirect
-	github.com/gocolly/colly/v2 v2.1.0 // indirect
-	github.com/golang/groupcache v0.0.0-20200121045136-8c9f03a8e57e // indirect
-	github.com/golang/protobuf v1.5.2 // indirect
-	github.com/inconshreveable/mousetrap v1.1.0 // indirect
-	github.com/kennygrant/sanitize v1.2.4 // indirect
 	github.com/oschwald/maxminddb-golang v1.13.0 // indirect
 	github.com/russross/blackfriday/v2 v2.1.0 // indirect
 	github.com/saintfish/chardet v0.0.0-20120816061221-3af4cd4741ca // indirect
-	github.com/spf13/cobra v1.7.0 // indirect
-	github.com/spf13/pflag v1.0.5 // indirect
-	github.com/temoto/robotstxt v1.1.1 // indirect
 	github.com/xrash/smetrics v0.0.0-20240521201337-686a1a2994c1 // indirect
 	github.com/zonedb/zonedb v1.0.3544 // indirect
-	go.zakaria.org/sghs v1.2.0 // indirect
-	golang.org/x/net v0.31.0 // indirect
 	golang.org/x/sys v0.27.0 // indirect
 	golang.org/x/text v0.20.0 // indirect
-	golang.org/x/time v0.0.0-20210723032227-1f47c861a9ac // indirect
-	google.golang.org/appengine v1.6.7 // indirect
-	google.golang.org/protobuf v1.26.0 // indirect
 )
@@ -0,0 +1,123 @@
+package alert
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/qepting91/gomain_analysis/internal/diff"
+	"github.com/qepting91/gomain_analysis/internal/transport"
+)
+
+// Alert is raised when a monitored domain changed since its previous scan
+type Alert struct {
+	Domain     string     `json:"domain"`
+	DetectedAt time.Time  `json:"detected_at"`
+	Summary    []string   `json:"summary"`
+	Diff       *diff.Diff `json:"diff"`
+}
+
+// New builds an alert from a diff
+func New(d *diff.Diff) Alert {
+	return Alert{
+		Domain:     d.Domain,
+		DetectedAt: time.Now().UTC(),
+		Summary:    d.Summary(),
+		Diff:       d,
+	}
+}
+
+// Notifier delivers alerts to a destination
+type Notifier interface {
+	Notify(ctx context.Context, a Alert) error
+}
+
+// WriterNotifier prints alerts as text, e.g. to stdout
+type WriterNotifier struct {
+	W io.Writer
+}
+
+// Notify implements Notifier
+func (n *WriterNotifier) Notify(ctx context.Context, a Alert) error {
+	fmt.Fprintf(n.W, "\n[ALERT] %s: %s\n", a.Domain, strings.Join(a.Summary, "; "))
+	return diff.WriteText(n.W, a.Diff)
+}
+
+// FileNotifier appends alerts to a file as JSON lines
+type FileNotifier struct {
+	Path string
+	mu   sync.Mutex
+}
+
+// Notify implements Notifier
+func (n *FileNotifier) Notify(ctx context.Context, a Alert) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	file, err := os.OpenFile(n.Path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open alert file %s: %v", n.Path, err)
+	}
+	defer file.Close()
+	if err := json.NewEncoder(file).Encode(a); err != nil {
+		return fmt.Errorf("failed to write alert to %s: %v", n.Path, err)
+	}
+	return nil
+}
+
+// WebhookNotifier POSTs alerts as JSON to a URL
+type WebhookNotifier struct {
+	URL    string
+	Client *http.Client
+}
+
+// Notify implements Notifier
+func (n *WebhookNotifier) Notify(ctx context.Context, a Alert) error {
+	body, err := json.Marshal(a)
+	if err != nil {
+		return fmt.Errorf("failed to encode alert: %v", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create webhook request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := n.Client
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second, Transport: transport.RoundTripper()}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to deliver alert to webhook: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status code: %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Parse builds a notifier from a destination spec: "stdout", "file:<path>",
+// "webhook:<url>" or a bare http(s) URL
+func Parse(spec string, stdout io.Writer) (Notifier, error) {
+	switch {
+	case spec == "stdout":
+		return &WriterNotifier{W: stdout}, nil
+	case strings.HasPrefix(spec, "file:"):
+		return &FileNotifier{Path: strings.TrimPrefix(spec, "file:")}, nil
+	case strings.HasPrefix(spec, "webhook:"):
+		return &WebhookNotifier{URL: strings.TrimPrefix(spec, "webhook:")}, nil
+	case strings.HasPrefix(spec, "http://"), strings.HasPrefix(spec, "https://"):
+		return &WebhookNotifier{URL: spec}, nil
+	default:
+		return nil, fmt.Errorf("unknown alert destination %q (use stdout, file:<path> or webhook:<url>)", spec)
+	}
+}
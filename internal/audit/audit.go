@@ -0,0 +1,167 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Kinds of network actions
+const (
+	KindHTTP  = "http"
+	KindDNS   = "dns"
+	KindWHOIS = "whois"
+	KindExec  = "exec"
+)
+
+// Entry is one line of the audit log. Only the fields that apply to the
+// kind of action are set.
+type Entry struct {
+	// Time is when the action started
+	Time time.Time `json:"time"`
+	Kind string    `json:"kind"`
+	// Module is the analysis module that caused the action, if any
+	Module string `json:"module,omitempty"`
+
+	Method string `json:"method,omitempty"`
+	URL    string `json:"url,omitempty"`
+	Status int    `json:"status,omitempty"`
+	// Bytes is the size of the response body as read
+	Bytes int64 `json:"bytes,omitempty"`
+
+	// Query is what was asked, e.g. "A example.com" or the WHOIS domain
+	Query    string `json:"query,omitempty"`
+	Resolver string `json:"resolver,omitempty"`
+	Server   string `json:"server,omitempty"`
+	Command  string `json:"command,omitempty"`
+
+	DurationMS int64  `json:"duration_ms"`
+	Error      string `json:"error,omitempty"`
+}
+
+// Log appends entries to a JSONL file
+type Log struct {
+	mu sync.Mutex
+	f  *os.File
+}
+
+// Open appends to the log at path, creating it if needed
+func Open(path string) (*Log, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %v", err)
+	}
+	return &Log{f: f}, nil
+}
+
+func (l *Log) write(e Entry) error {
+	// Commands and URLs stay readable with < and & left unescaped
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(e); err != nil {
+		return err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, err := l.f.Write(buf.Bytes())
+	return err
+}
+
+var active atomic.Pointer[Log]
+
+// Enable makes l the process-wide audit log; nil disables auditing
+func Enable(l *Log) {
+	active.Store(l)
+}
+
+type moduleKey struct{}
+
+// WithModule attributes the network actions taken with ctx to module
+func WithModule(ctx context.Context, module string) context.Context {
+	return context.WithValue(ctx, moduleKey{}, module)
+}
+
+// Module returns the module set by WithModule, or ""
+func Module(ctx context.Context) string {
+	module, _ := ctx.Value(moduleKey{}).(string)
+	return module
+}
+
+// Record logs e, which started at e.Time and ended now with err. The
+// module is taken from ctx. Failing to write the log never fails the action.
+func Record(ctx context.Context, e Entry, err error) {
+	l := active.Load()
+	if l == nil {
+		return
+	}
+	e.Module = Module(ctx)
+	e.DurationMS = time.Since(e.Time).Milliseconds()
+	e.Time = e.Time.UTC()
+	if err != nil {
+		e.Error = err.Error()
+	}
+	if err := l.write(e); err != nil {
+		log.Printf("Error writing audit log: %v", err)
+	}
+}
+
+type transport struct {
+	base http.RoundTripper
+}
+
+// Transport wraps base so every request it sends is logged. The entry is
+// written once the response body is closed, so it carries the bytes read.
+func Transport(base http.RoundTripper) http.RoundTripper {
+	return &transport{base: base}
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if active.Load() == nil {
+		return t.base.RoundTrip(req)
+	}
+	e := Entry{Time: time.Now(), Kind: KindHTTP, Method: req.Method, URL: req.URL.String()}
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		Record(req.Context(), e, err)
+		return resp, err
+	}
+	e.Status = resp.StatusCode
+	resp.Body = &countingBody{ReadCloser: resp.Body, done: func(n int64, err error) {
+		e.Bytes = n
+		Record(req.Context(), e, err)
+	}}
+	return resp, nil
+}
+
+// countingBody counts what is read and reports it once, when closed
+type countingBody struct {
+	io.ReadCloser
+	n       int64
+	readErr error
+	once    sync.Once
+	done    func(n int64, err error)
+}
+
+func (b *countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.n += int64(n)
+	if err != nil && err != io.EOF {
+		b.readErr = err
+	}
+	return n, err
+}
+
+func (b *countingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(func() { b.done(b.n, b.readErr) })
+	return err
+}
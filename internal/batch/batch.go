@@ -0,0 +1,259 @@
+package batch
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/qepting91/gomain_analysis/internal/model"
+	"github.com/qepting91/gomain_analysis/internal/pipeline"
+	"github.com/qepting91/gomain_analysis/internal/report"
+	"github.com/qepting91/gomain_analysis/internal/store"
+	"github.com/qepting91/gomain_analysis/internal/target"
+)
+
+// Per-domain outcomes recorded in Result
+const (
+	StatusSucceeded = "succeeded"
+	StatusPartial   = "partial"
+	StatusFailed    = "failed"
+	StatusCancelled = "cancelled"
+)
+
+// IndexFile is the name of the summary written into the output directory
+const IndexFile = "index.json"
+
+// Result records the outcome of analyzing a single domain
+type Result struct {
+	Domain        string        `json:"domain"`
+	Status        string        `json:"status"`
+	Output        string        `json:"output,omitempty"`
+	ScanID        uint64        `json:"scan_id,omitempty"`
+	Error         string        `json:"error,omitempty"`
+	FailedModules []string      `json:"failed_modules,omitempty"`
+	Started       time.Time     `json:"started"`
+	Duration      time.Duration `json:"duration_ns"`
+}
+
+// Summary is the index of a batch run
+type Summary struct {
+	Started   time.Time     `json:"started"`
+	Finished  time.Time     `json:"finished"`
+	Duration  time.Duration `json:"duration_ns"`
+	Profile   string        `json:"profile"`
+	Format    string        `json:"format"`
+	Total     int           `json:"total"`
+	Succeeded int           `json:"succeeded"`
+	Partial   int           `json:"partial"`
+	Failed    int           `json:"failed"`
+	Cancelled int           `json:"cancelled"`
+	Results   []Result      `json:"results"`
+}
+
+// Runner analyzes many domains with a bounded pool of workers. The selected
+// steps, and the HTTP clients and resolvers behind them, are shared by all workers.
+type Runner struct {
+	Selection *pipeline.Selection
+	Workers   int
+	Timeout   time.Duration
+	Format    string
+	OutputDir string
+	// FileName is the report naming pattern passed to report.OutputName
+	FileName string
+	Progress io.Writer
+	// Store, when set, receives every report for the scan history
+	Store *store.Store
+}
+
+// ReadDomains reads one domain, URL or IP per line and normalizes it,
+// skipping blank lines, # comments and duplicates. Lines that are not valid
+// targets are logged and skipped.
+func ReadDomains(r io.Reader) ([]string, error) {
+	var domains []string
+	seen := make(map[string]bool)
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		domain, err := target.Normalize(line)
+		if err != nil {
+			log.Printf("Skipping line %d: %v", lineNo, err)
+			continue
+		}
+		if seen[domain] {
+			continue
+		}
+		seen[domain] = true
+		domains = append(domains, domain)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read domain list: %v", err)
+	}
+	return domains, nil
+}
+
+// Run analyzes every domain and returns the summary. Domains that have not
+// started when ctx is cancelled are recorded as cancelled.
+func (b *Runner) Run(ctx context.Context, domains []string) *Summary {
+	summary := &Summary{
+		Started: time.Now().UTC(),
+		Profile: b.Selection.Profile,
+		Format:  b.Format,
+		Total:   len(domains),
+		Results: make([]Result, len(domains)),
+	}
+
+	workers := b.Workers
+	if workers < 1 {
+		workers = 1
+	}
+	progress := b.Progress
+	if progress == nil {
+		progress = io.Discard
+	}
+
+	jobs := make(chan int)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	finished := 0
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				result := b.analyze(ctx, domains[i])
+				summary.Results[i] = result
+
+				mu.Lock()
+				finished++
+				line := fmt.Sprintf("[%d/%d] %s: %s in %s", finished, len(domains), result.Domain, result.Status, result.Duration.Round(time.Second))
+				if len(result.FailedModules) > 0 {
+					line += fmt.Sprintf(" (failed: %s)", strings.Join(result.FailedModules, ", "))
+				}
+				if result.Error != "" {
+					line += " - " + result.Error
+				}
+				fmt.Fprintln(progress, line)
+				mu.Unlock()
+			}
+		}()
+	}
+
+	next := 0
+dispatch:
+	for ; next < len(domains); next++ {
+		select {
+		case jobs <- next:
+		case <-ctx.Done():
+			break dispatch
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	for ; next < len(domains); next++ {
+		summary.Results[next] = Result{Domain: domains[next], Status: StatusCancelled, Error: ctx.Err().Error()}
+	}
+
+	for _, result := range summary.Results {
+		switch result.Status {
+		case StatusSucceeded:
+			summary.Succeeded++
+		case StatusPartial:
+			summary.Partial++
+		case StatusFailed:
+			summary.Failed++
+		case StatusCancelled:
+			summary.Cancelled++
+		}
+	}
+	summary.Finished = time.Now().UTC()
+	summary.Duration = summary.Finished.Sub(summary.Started)
+	return summary
+}
+
+func (b *Runner) analyze(ctx context.Context, domain string) (result Result) {
+	result = Result{Domain: domain, Started: time.Now().UTC()}
+	defer func() { result.Duration = time.Since(result.Started) }()
+
+	scanCtx := ctx
+	if b.Timeout > 0 {
+		var cancel context.CancelFunc
+		scanCtx, cancel = context.WithTimeout(ctx, b.Timeout)
+		defer cancel()
+	}
+
+	r, err := pipeline.Analyze(scanCtx, domain, b.Selection, nil)
+	if err != nil {
+		result.Status = StatusFailed
+		result.Error = err.Error()
+		return result
+	}
+
+	result.Output = filepath.Join(b.OutputDir, report.OutputName(b.FileName, safeFileName(domain), b.Format))
+	if err := report.WriteFile(result.Output, b.Format, r); err != nil {
+		result.Status = StatusFailed
+		result.Error = err.Error()
+		result.Output = ""
+		return result
+	}
+
+	if b.Store != nil {
+		if result.ScanID, err = b.Store.Save(r); err != nil {
+			result.Error = err.Error()
+		}
+	}
+
+	for _, m := range r.Modules {
+		if m.Status != model.StatusCompleted {
+			result.FailedModules = append(result.FailedModules, m.Name)
+		}
+	}
+	switch {
+	case ctx.Err() != nil:
+		result.Status = StatusCancelled
+		result.Error = "interrupted, partial report written"
+	case len(result.FailedModules) > 0:
+		result.Status = StatusPartial
+	default:
+		result.Status = StatusSucceeded
+	}
+	return result
+}
+
+// WriteSummary writes the summary as indented JSON
+func WriteSummary(path string, summary *Summary) error {
+	data, err := json.MarshalIndent(summary, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode batch summary: %v", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write batch summary %s: %v", path, err)
+	}
+	return nil
+}
+
+// safeFileName replaces anything that is not valid in a hostname so a
+// malformed input line cannot escape the output directory
+func safeFileName(domain string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-':
+			return r
+		default:
+			return '_'
+		}
+	}, domain)
+}
@@ -0,0 +1,286 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+)
+
+// Sources of cacheable answers. Each has its own TTL and sub-directory.
+const (
+	// SourcePEM is a certificate downloaded from crt.sh by its immutable ID
+	SourcePEM = "pem"
+	// SourceCT is a crt.sh search for the certificates of a domain
+	SourceCT = "crtsh"
+	// SourceSearch is a search engine results page
+	SourceSearch = "search"
+	// SourceWHOIS is a raw WHOIS record
+	SourceWHOIS = "whois"
+	// SourceWayback is a Wayback Machine availability answer
+	SourceWayback = "wayback"
+)
+
+// DefaultTTLs keeps certificates effectively forever, WHOIS and Wayback data
+// for a day, and search results for an hour
+func DefaultTTLs() map[string]time.Duration {
+	return map[string]time.Duration{
+		SourcePEM:     10 * 365 * 24 * time.Hour,
+		SourceCT:      time.Hour,
+		SourceSearch:  time.Hour,
+		SourceWHOIS:   24 * time.Hour,
+		SourceWayback: 24 * time.Hour,
+	}
+}
+
+// entry is a cached answer as stored on disk
+type entry struct {
+	Key      string    `json:"key"`
+	StoredAt time.Time `json:"stored_at"`
+	Data     []byte    `json:"data"`
+}
+
+// Cache stores answers under a directory, one file per key
+type Cache struct {
+	dir string
+	ttl map[string]time.Duration
+}
+
+var active atomic.Pointer[Cache]
+
+// New returns a cache rooted at dir. Sources missing from ttl, or with a TTL
+// of zero, are not cached.
+func New(dir string, ttl map[string]time.Duration) *Cache {
+	return &Cache{dir: dir, ttl: ttl}
+}
+
+// Enable makes c the process-wide cache used by Do and Transport; nil disables caching
+func Enable(c *Cache) {
+	active.Store(c)
+}
+
+type sourceKey struct{}
+
+// WithSource marks HTTP requests made with ctx as cacheable under source.
+// Requests without a source always go to the network.
+func WithSource(ctx context.Context, source string) context.Context {
+	return context.WithValue(ctx, sourceKey{}, source)
+}
+
+func sourceFrom(ctx context.Context) string {
+	source, _ := ctx.Value(sourceKey{}).(string)
+	return source
+}
+
+// Do returns the cached answer for key if it is fresh, and otherwise calls fn
+// and caches its answer. Errors are never cached.
+func Do(source, key string, fn func() ([]byte, error)) ([]byte, error) {
+	c := active.Load()
+	if c == nil || c.ttl[source] <= 0 {
+		return fn()
+	}
+	if data, ok := c.get(source, key); ok {
+		return data, nil
+	}
+	data, err := fn()
+	if err != nil {
+		return data, err
+	}
+	if err := c.put(source, key, data); err != nil {
+		log.Printf("Error writing cache entry: %v", err)
+	}
+	return data, nil
+}
+
+func (c *Cache) path(source, key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(c.dir, source, hex.EncodeToString(sum[:16])+".json")
+}
+
+func (c *Cache) get(source, key string) ([]byte, bool) {
+	raw, err := os.ReadFile(c.path(source, key))
+	if err != nil {
+		return nil, false
+	}
+	var e entry
+	if err := json.Unmarshal(raw, &e); err != nil || e.Key != key {
+		return nil, false
+	}
+	if time.Since(e.StoredAt) > c.ttl[source] {
+		return nil, false
+	}
+	return e.Data, true
+}
+
+// put writes the entry to a temporary file first so concurrent readers never
+// see a partial entry
+func (c *Cache) put(source, key string, data []byte) error {
+	raw, err := json.Marshal(entry{Key: key, StoredAt: time.Now().UTC(), Data: data})
+	if err != nil {
+		return err
+	}
+	p := c.path(source, key)
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(p), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(raw); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), p)
+}
+
+// PruneStats reports what Prune removed and kept
+type PruneStats struct {
+	Removed int
+	Freed   int64
+	Kept    int
+}
+
+// Sources lists every source the cache stores answers for
+func Sources() []string {
+	return []string{SourcePEM, SourceCT, SourceSearch, SourceWHOIS, SourceWayback}
+}
+
+// Prune deletes expired entries, or every entry when all is set. Entries of
+// sources that are no longer cached count as expired. Only the source
+// sub-directories are searched, and only files that really are cache entries
+// are removed, so a cache directory pointed at the wrong place loses nothing.
+func (c *Cache) Prune(all bool) (PruneStats, error) {
+	var stats PruneStats
+	for _, source := range Sources() {
+		err := filepath.WalkDir(filepath.Join(c.dir, source), func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				if errors.Is(err, fs.ErrNotExist) {
+					return nil
+				}
+				return err
+			}
+			if d.IsDir() {
+				if path != filepath.Join(c.dir, source) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if filepath.Ext(path) != ".json" {
+				return nil
+			}
+			raw, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			var e entry
+			if json.Unmarshal(raw, &e) != nil || e.Key == "" || c.path(source, e.Key) != path {
+				return nil
+			}
+
+			if !all && time.Since(e.StoredAt) <= c.ttl[source] {
+				stats.Kept++
+				return nil
+			}
+			if err := os.Remove(path); err != nil {
+				return err
+			}
+			stats.Removed++
+			stats.Freed += int64(len(raw))
+			return nil
+		})
+		if err != nil {
+			return stats, fmt.Errorf("failed to prune cache: %v", err)
+		}
+	}
+	return stats, nil
+}
+
+// response is the cached form of an HTTP response
+type response struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header"`
+	Body       []byte      `json:"body"`
+}
+
+type transport struct {
+	base http.RoundTripper
+}
+
+// Transport wraps base so that GET requests carrying a source (see
+// WithSource) are answered from the cache while fresh. Only 200 responses
+// are stored.
+func Transport(base http.RoundTripper) http.RoundTripper {
+	return &transport{base: base}
+}
+
+// errNotCacheable carries a response that must be passed through uncached
+type errNotCacheable struct {
+	resp *http.Response
+}
+
+func (e *errNotCacheable) Error() string {
+	return "response not cacheable"
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	source := sourceFrom(req.Context())
+	if source == "" || req.Method != http.MethodGet {
+		return t.base.RoundTrip(req)
+	}
+
+	data, err := Do(source, req.URL.String(), func() ([]byte, error) {
+		resp, err := t.base.RoundTrip(req)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != http.StatusOK {
+			return nil, &errNotCacheable{resp: resp}
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(response{StatusCode: resp.StatusCode, Header: resp.Header, Body: body})
+	})
+	var uncached *errNotCacheable
+	if errors.As(err, &uncached) {
+		return uncached.resp, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var cached response
+	if err := json.Unmarshal(data, &cached); err != nil {
+		return nil, fmt.Errorf("failed to decode cached response: %v", err)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", cached.StatusCode, http.StatusText(cached.StatusCode)),
+		StatusCode:    cached.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        cached.Header,
+		Body:          io.NopCloser(bytes.NewReader(cached.Body)),
+		ContentLength: int64(len(cached.Body)),
+		Request:       req,
+	}, nil
+}
@@ -0,0 +1,214 @@
+package cache
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// age rewrites the stored time of a cached entry to d ago
+func age(t *testing.T, c *Cache, source, key string, d time.Duration) {
+	t.Helper()
+	p := c.path(source, key)
+	raw, err := os.ReadFile(p)
+	if err != nil {
+		t.Fatalf("reading entry: %v", err)
+	}
+	var e entry
+	if err := json.Unmarshal(raw, &e); err != nil {
+		t.Fatalf("decoding entry: %v", err)
+	}
+	e.StoredAt = time.Now().Add(-d)
+	if raw, err = json.Marshal(e); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(p, raw, 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDoTTL(t *testing.T) {
+	c := New(t.TempDir(), map[string]time.Duration{SourceWHOIS: time.Hour, SourceSearch: 0})
+	Enable(c)
+	defer Enable(nil)
+
+	calls := 0
+	fetch := func() ([]byte, error) {
+		calls++
+		return []byte("answer"), nil
+	}
+	tests := []struct {
+		name      string
+		source    string
+		age       time.Duration
+		wantCalls int
+	}{
+		{"first lookup fetches", SourceWHOIS, 0, 1},
+		{"fresh entry is served from the cache", SourceWHOIS, 59 * time.Minute, 0},
+		{"expired entry is fetched again", SourceWHOIS, 61 * time.Minute, 1},
+		{"source without a TTL is never cached", SourceSearch, 0, 1},
+		{"source without a TTL is fetched every time", SourceSearch, 0, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.age > 0 {
+				age(t, c, tt.source, "example.com", tt.age)
+			}
+			calls = 0
+			data, err := Do(tt.source, "example.com", fetch)
+			if err != nil || string(data) != "answer" {
+				t.Fatalf("Do = %q, %v", data, err)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("fetched %d times, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestDoDoesNotCacheErrors(t *testing.T) {
+	c := New(t.TempDir(), map[string]time.Duration{SourceWHOIS: time.Hour})
+	Enable(c)
+	defer Enable(nil)
+
+	if _, err := Do(SourceWHOIS, "example.com", func() ([]byte, error) { return nil, errors.New("timeout") }); err == nil {
+		t.Fatal("Do swallowed the error")
+	}
+	calls := 0
+	if _, err := Do(SourceWHOIS, "example.com", func() ([]byte, error) { calls++; return []byte("answer"), nil }); err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	if calls != 1 {
+		t.Error("a failed lookup was cached")
+	}
+}
+
+func TestPrune(t *testing.T) {
+	c := New(t.TempDir(), map[string]time.Duration{SourceWHOIS: time.Hour, SourceWayback: time.Hour})
+	for _, key := range []string{"fresh", "stale"} {
+		if err := c.put(SourceWHOIS, key, []byte(key)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	age(t, c, SourceWHOIS, "stale", 2*time.Hour)
+	// Entries of a source that is no longer cached count as expired
+	if err := c.put(SourceCT, "dropped", []byte("x")); err != nil {
+		t.Fatal(err)
+	}
+
+	stats, err := c.Prune(false)
+	if err != nil {
+		t.Fatalf("Prune: %v", err)
+	}
+	if stats.Removed != 2 || stats.Kept != 1 {
+		t.Errorf("Prune = %+v, want 2 removed and 1 kept", stats)
+	}
+	if _, ok := c.get(SourceWHOIS, "fresh"); !ok {
+		t.Error("fresh entry was pruned")
+	}
+
+	if stats, err = c.Prune(true); err != nil || stats.Removed != 1 {
+		t.Errorf("Prune(all) = %+v, %v", stats, err)
+	}
+	if _, err := os.Stat(c.path(SourceWHOIS, "fresh")); !os.IsNotExist(err) {
+		t.Errorf("entry left after pruning everything: %v", err)
+	}
+}
+
+func TestPruneKeepsForeignFiles(t *testing.T) {
+	c := New(t.TempDir(), map[string]time.Duration{SourceWHOIS: time.Hour})
+	if err := c.put(SourceWHOIS, "example.com", []byte("answer")); err != nil {
+		t.Fatal(err)
+	}
+	foreign := map[string]string{
+		"notes.txt":                  "not a cache entry",
+		"config.json":                `{"key":"value"}`,
+		"projects/report.json":       `{"key":"report","stored_at":"2000-01-01T00:00:00Z"}`,
+		"whois/readme.txt":           "text",
+		"whois/settings.json":        `{"theme":"dark"}`,
+		"whois/broken.json":          "{",
+		"whois/renamed.json":         `{"key":"example.org","stored_at":"2000-01-01T00:00:00Z"}`,
+		"whois/nested/entry.json":    `{"key":"nested","stored_at":"2000-01-01T00:00:00Z"}`,
+		"crtsh/.tmp-partial":         "partial write",
+		"wayback/old-format.json.gz": "data",
+	}
+	for name, content := range foreign {
+		p := filepath.Join(c.dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	stats, err := c.Prune(true)
+	if err != nil {
+		t.Fatalf("Prune: %v", err)
+	}
+	if stats.Removed != 1 || stats.Kept != 0 {
+		t.Errorf("Prune = %+v, want only the cache entry removed", stats)
+	}
+	for name := range foreign {
+		if _, err := os.Stat(filepath.Join(c.dir, filepath.FromSlash(name))); err != nil {
+			t.Errorf("%s was deleted: %v", name, err)
+		}
+	}
+}
+
+type countingTransport struct {
+	calls  int
+	status int
+}
+
+func (t *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.calls++
+	return &http.Response{StatusCode: t.status, Header: http.Header{}, Body: io.NopCloser(strings.NewReader("body"))}, nil
+}
+
+func TestTransport(t *testing.T) {
+	c := New(t.TempDir(), map[string]time.Duration{SourceCT: time.Hour})
+	Enable(c)
+	defer Enable(nil)
+
+	tests := []struct {
+		name      string
+		source    string
+		status    int
+		wantCalls int
+	}{
+		{"untagged requests are not cached", "", http.StatusOK, 2},
+		{"tagged 200 responses are cached", SourceCT, http.StatusOK, 1},
+		{"errors are not cached", SourceCT, http.StatusServiceUnavailable, 2},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base := &countingTransport{status: tt.status}
+			client := &http.Client{Transport: Transport(base)}
+			for n := 0; n < 2; n++ {
+				req, _ := http.NewRequest(http.MethodGet, "https://crt.sh/?q=example.com&case="+string(rune('a'+i)), nil)
+				if tt.source != "" {
+					req = req.WithContext(WithSource(req.Context(), tt.source))
+				}
+				resp, err := client.Do(req)
+				if err != nil {
+					t.Fatalf("request %d: %v", n, err)
+				}
+				body, _ := io.ReadAll(resp.Body)
+				resp.Body.Close()
+				if resp.StatusCode != tt.status || string(body) != "body" {
+					t.Errorf("request %d: %d %q", n, resp.StatusCode, body)
+				}
+			}
+			if base.calls != tt.wantCalls {
+				t.Errorf("%d requests reached the network, want %d", base.calls, tt.wantCalls)
+			}
+		})
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"sync"
 
 	"github.com/oschwald/geoip2-golang"
 )
@@ -12,9 +13,24 @@ import (
 // GeoLiteDB represents the GeoLite2 database file
 var GeoLiteDB *geoip2.Reader
 
-// Initialize loads the GeoLite2 database from the provided file path
+// Path is where Initialize looks for the database; set it before the first lookup
+var Path = filepath.Join("assets", "GeoLite2-City.mmdb")
+
+var (
+	initOnce sync.Once
+	initErr  error
+)
+
+// Initialize loads the GeoLite2 database from Path. Only the first call opens
+// the file; later calls return the same result, so lookups can call it lazily.
 func Initialize() error {
-	dbPath := filepath.Join("assets", "GeoLite2-City.mmdb")
+	initOnce.Do(func() {
+		initErr = open(Path)
+	})
+	return initErr
+}
+
+func open(dbPath string) error {
 	if _, err := os.Stat(dbPath); os.IsNotExist(err) {
 		return fmt.Errorf("GeoLite2 database file not found at %s", dbPath)
 	}
@@ -36,17 +52,3 @@ func Close() {
 		log.Println("GeoLite2 database closed successfully")
 	}
 }
-
-// LookupIP takes an IP address and returns geolocation information
-func LookupIP(ip string) (*geoip2.City, error) {
-	if GeoLiteDB == nil {
-		return nil, fmt.Errorf("GeoLite2 database is not initialized")
-	}
-
-	record, err := GeoLiteDB.City([]byte(ip))
-	if err != nil {
-		return nil, fmt.Errorf("failed to lookup IP: %v", err)
-	}
-
-	return record, nil
-}
@@ -1,36 +1,39 @@
 package crt
 
 import (
+	"context"
+	"crypto/sha1"
+	"crypto/sha256"
 	"crypto/x509"
+	"encoding/hex"
 	"encoding/json"
 	"encoding/pem"
-	"flag"
 	"fmt"
 	"io"
-	"log"
 	"net/http"
-	"os"
 	"time"
+
+	"github.com/qepting91/gomain_analysis/internal/cache"
+	"github.com/qepting91/gomain_analysis/internal/model"
+	"github.com/qepting91/gomain_analysis/internal/transport"
 )
 
 // CRTSHURL is the base URL for crt.sh
 const CRTSHURL = "https://crt.sh"
 
 // CTLog represents a single record from crt.sh
-type CTLog struct {
-	IssuerCaID        int    `json:"issuer_ca_id"`
-	IssuerName        string `json:"issuer_name"`
-	NameValue         string `json:"name_value"`
-	MinCertID         int    `json:"min_cert_id"`
-	MinEntryTimestamp string `json:"min_entry_timestamp"`
-	NotBefore         string `json:"not_before"`
-	NotAfter          string `json:"not_after"`
-}
+type CTLog = model.CTLog
+
+// httpClient is reused across crt.sh queries and PEM downloads
+var httpClient = transport.NewClient(10 * time.Second)
 
 // queryCrtsh sends an HTTP GET request to crt.sh and returns the response body.
-func QueryCrtsh(url string) ([]byte, error) {
-	client := &http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Get(url)
+func QueryCrtsh(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create crt.sh request: %v", err)
+	}
+	resp, err := httpClient.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query crt.sh: %v", err)
 	}
@@ -48,9 +51,9 @@ func QueryCrtsh(url string) ([]byte, error) {
 }
 
 // queryByDomain queries crt.sh for certificates by domain.
-func QueryByDomain(domain string) ([]CTLog, error) {
+func QueryByDomain(ctx context.Context, domain string) ([]CTLog, error) {
 	url := fmt.Sprintf("%s/?output=json&q=%s", CRTSHURL, domain)
-	body, err := QueryCrtsh(url)
+	body, err := QueryCrtsh(cache.WithSource(ctx, cache.SourceCT), url)
 	if err != nil {
 		return nil, err
 	}
@@ -63,9 +66,11 @@ func QueryByDomain(domain string) ([]CTLog, error) {
 }
 
 // downloadPemFile downloads a PEM file for the given certificate ID.
-func DownloadPemFile(certID int) ([]byte, error) {
+func DownloadPemFile(ctx context.Context, certID int) ([]byte, error) {
 	url := fmt.Sprintf("%s/?d=%d", CRTSHURL, certID)
-	return QueryCrtsh(url)
+	// A certificate ID always refers to the same certificate, so the PEM
+	// can be cached for as long as the cache allows
+	return QueryCrtsh(cache.WithSource(ctx, cache.SourcePEM), url)
 }
 
 // parseCertificate parses a PEM-encoded certificate and returns an x509.Certificate.
@@ -82,69 +87,34 @@ func ParseCertificate(pemData []byte) (*x509.Certificate, error) {
 	return cert, nil
 }
 
-// printCertDetails prints details from an x509.Certificate.
-func PrintCertDetails(cert *x509.Certificate) {
-	fmt.Println("Certificate Details:")
-	fmt.Printf("  Subject: %s\n", cert.Subject)
-	fmt.Printf("  Issuer: %s\n", cert.Issuer)
-	fmt.Printf("  Valid From: %s\n", cert.NotBefore)
-	fmt.Printf("  Valid To: %s\n", cert.NotAfter)
-	fmt.Println("  DNS Names:")
-	for _, dnsName := range cert.DNSNames {
-		fmt.Printf("    - %s\n", dnsName)
+// NewCertificate combines a crt.sh log entry and its parsed certificate into a report entry.
+func NewCertificate(log CTLog, cert *x509.Certificate) model.Certificate {
+	sha1Sum := sha1.Sum(cert.Raw)
+	sha256Sum := sha256.Sum256(cert.Raw)
+	return model.Certificate{
+		ID:                log.MinCertID,
+		LoggedAt:          log.MinEntryTimestamp,
+		NameValue:         log.NameValue,
+		Subject:           cert.Subject.String(),
+		Issuer:            cert.Issuer.String(),
+		SerialNumber:      cert.SerialNumber.String(),
+		NotBefore:         cert.NotBefore,
+		NotAfter:          cert.NotAfter,
+		DNSNames:          cert.DNSNames,
+		FingerprintSHA1:   hex.EncodeToString(sha1Sum[:]),
+		FingerprintSHA256: hex.EncodeToString(sha256Sum[:]),
 	}
 }
 
-// main function parses flags and runs the program.
-func main() {
-	var domain string
-	var certID int
-	var showOnlyDomains bool
-
-	flag.StringVar(&domain, "domain", "", "Domain to search for (e.g., example.com)")
-	flag.IntVar(&certID, "cert", 0, "Certificate ID to download and parse")
-	flag.BoolVar(&showOnlyDomains, "only-domains", false, "Show only domains in the results")
-	flag.Parse()
-
-	if domain == "" && certID == 0 {
-		fmt.Println("Usage:")
-		fmt.Println("  -domain <domain>       Query crt.sh for certificates matching the domain")
-		fmt.Println("  -cert <certID>         Download and parse a specific certificate by ID")
-		fmt.Println("  -only-domains          Show only domains in the results (when using -domain)")
-		os.Exit(1)
-	}
-
-	if domain != "" {
-		logs, err := QueryByDomain(domain)
-		if err != nil {
-			log.Fatalf("Error querying domain: %v", err)
-		}
-
-		if showOnlyDomains {
-			fmt.Println("Domains:")
-			for _, log := range logs {
-				fmt.Println(log.NameValue)
-			}
-		} else {
-			fmt.Println("Certificates:")
-			for _, log := range logs {
-				fmt.Printf("Cert ID: %d, Domain: %s, Issuer: %s, Valid From: %s, Valid To: %s\n",
-					log.MinCertID, log.NameValue, log.IssuerName, log.NotBefore, log.NotAfter)
-			}
-		}
-	}
-
-	if certID != 0 {
-		pemData, err := DownloadPemFile(certID)
-		if err != nil {
-			log.Fatalf("Error downloading PEM file: %v", err)
-		}
-
-		cert, err := ParseCertificate(pemData)
-		if err != nil {
-			log.Fatalf("Error parsing certificate: %v", err)
-		}
-
-		PrintCertDetails(cert)
+// printCertDetails prints details from an x509.Certificate.
+func PrintCertDetails(w io.Writer, cert *x509.Certificate) {
+	fmt.Fprintln(w, "Certificate Details:")
+	fmt.Fprintf(w, "  Subject: %s\n", cert.Subject)
+	fmt.Fprintf(w, "  Issuer: %s\n", cert.Issuer)
+	fmt.Fprintf(w, "  Valid From: %s\n", cert.NotBefore)
+	fmt.Fprintf(w, "  Valid To: %s\n", cert.NotAfter)
+	fmt.Fprintln(w, "  DNS Names:")
+	for _, dnsName := range cert.DNSNames {
+		fmt.Fprintf(w, "    - %s\n", dnsName)
 	}
 }
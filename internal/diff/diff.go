@@ -0,0 +1,232 @@
+package diff
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/qepting91/gomain_analysis/internal/model"
+	"github.com/qepting91/gomain_analysis/internal/parser"
+)
+
+// StringChanges lists values present in only one of the two scans
+type StringChanges struct {
+	Added   []string `json:"added,omitempty"`
+	Removed []string `json:"removed,omitempty"`
+}
+
+// Empty reports whether nothing was added or removed
+func (c StringChanges) Empty() bool {
+	return len(c.Added) == 0 && len(c.Removed) == 0
+}
+
+// CertificateChanges lists crt.sh entries present in only one of the two scans
+type CertificateChanges struct {
+	Added   []model.CTLog `json:"added,omitempty"`
+	Removed []model.CTLog `json:"removed,omitempty"`
+}
+
+// Empty reports whether nothing was added or removed
+func (c CertificateChanges) Empty() bool {
+	return len(c.Added) == 0 && len(c.Removed) == 0
+}
+
+// FieldChange records a single value that differs between the scans
+type FieldChange struct {
+	Field string `json:"field"`
+	Old   string `json:"old"`
+	New   string `json:"new"`
+}
+
+// Diff describes what changed between an older and a newer scan of a domain
+type Diff struct {
+	Domain         string             `json:"domain"`
+	OldScan        time.Time          `json:"old_scan"`
+	NewScan        time.Time          `json:"new_scan"`
+	Certificates   CertificateChanges `json:"certificates"`
+	Subdomains     StringChanges      `json:"subdomains"`
+	ARecords       StringChanges      `json:"a_records"`
+	MXRecords      StringChanges      `json:"mx_records"`
+	WHOIS          []FieldChange      `json:"whois,omitempty"`
+	Technologies   StringChanges      `json:"technologies"`
+	ExternalLinks  StringChanges      `json:"external_links"`
+	SocialProfiles StringChanges      `json:"social_profiles"`
+	// NotCompared lists modules that did not complete in both scans, whose
+	// sections would otherwise show everything as added or removed
+	NotCompared []string `json:"not_compared,omitempty"`
+}
+
+// HasChanges reports whether any compared section differs
+func (d *Diff) HasChanges() bool {
+	return !d.Certificates.Empty() || !d.Subdomains.Empty() || !d.ARecords.Empty() ||
+		!d.MXRecords.Empty() || len(d.WHOIS) > 0 ||
+		!d.Technologies.Empty() || !d.ExternalLinks.Empty() || !d.SocialProfiles.Empty()
+}
+
+// Summary returns one short line per changed section, for alert titles
+func (d *Diff) Summary() []string {
+	var lines []string
+	count := func(n int, format string) {
+		if n > 0 {
+			lines = append(lines, fmt.Sprintf(format, n))
+		}
+	}
+	count(len(d.Certificates.Added), "%d new certificate(s)")
+	count(len(d.Certificates.Removed), "%d removed certificate(s)")
+	count(len(d.Subdomains.Added), "%d new subdomain(s)")
+	count(len(d.Subdomains.Removed), "%d removed subdomain(s)")
+	if !d.ARecords.Empty() {
+		lines = append(lines, "A records changed")
+	}
+	if !d.MXRecords.Empty() {
+		lines = append(lines, "MX records changed")
+	}
+	for _, change := range d.WHOIS {
+		lines = append(lines, fmt.Sprintf("WHOIS %s changed", change.Field))
+	}
+	count(len(d.Technologies.Added), "%d new technology signature(s)")
+	count(len(d.ExternalLinks.Added), "%d new external link(s)")
+	count(len(d.SocialProfiles.Added), "%d new social profile(s)")
+	return lines
+}
+
+// Compare returns the differences between two scans of the same domain
+func Compare(older, newer *model.DomainReport) (*Diff, error) {
+	if older.Domain != newer.Domain {
+		return nil, fmt.Errorf("cannot compare scans of different domains: %s and %s", older.Domain, newer.Domain)
+	}
+	if newer.GeneratedAt.Before(older.GeneratedAt) {
+		older, newer = newer, older
+	}
+
+	d := &Diff{
+		Domain:  newer.Domain,
+		OldScan: older.GeneratedAt,
+		NewScan: newer.GeneratedAt,
+	}
+	compared := func(module string) bool {
+		if older.ModuleCompleted(module) && newer.ModuleCompleted(module) {
+			return true
+		}
+		d.NotCompared = append(d.NotCompared, module)
+		return false
+	}
+
+	if compared("crt") {
+		d.Certificates = compareCTLogs(older.CTLogs, newer.CTLogs)
+		d.Subdomains = compareStrings(older.Subdomains(), newer.Subdomains())
+	}
+	if compared("dns") {
+		d.ARecords = compareStrings(older.DNS.ARecords, newer.DNS.ARecords)
+		d.MXRecords = compareStrings(mxStrings(older.DNS.MXRecords), mxStrings(newer.DNS.MXRecords))
+	}
+	if compared("whois") {
+		d.WHOIS = compareWHOIS(older.WHOIS, newer.WHOIS)
+	}
+	if compared("web") {
+		oldContent, newContent := webContent(older), webContent(newer)
+		d.Technologies = compareStrings(oldContent.Technologies, newContent.Technologies)
+		d.ExternalLinks = compareStrings(oldContent.ExternalLinks, newContent.ExternalLinks)
+		d.SocialProfiles = compareStrings(socialProfiles(oldContent.SocialMedia), socialProfiles(newContent.SocialMedia))
+	}
+	return d, nil
+}
+
+func compareStrings(older, newer []string) StringChanges {
+	oldSet := toSet(older)
+	newSet := toSet(newer)
+	var changes StringChanges
+	for value := range newSet {
+		if !oldSet[value] {
+			changes.Added = append(changes.Added, value)
+		}
+	}
+	for value := range oldSet {
+		if !newSet[value] {
+			changes.Removed = append(changes.Removed, value)
+		}
+	}
+	sort.Strings(changes.Added)
+	sort.Strings(changes.Removed)
+	return changes
+}
+
+func compareCTLogs(older, newer []model.CTLog) CertificateChanges {
+	oldIDs := make(map[int]bool, len(older))
+	for _, entry := range older {
+		oldIDs[entry.MinCertID] = true
+	}
+	newIDs := make(map[int]bool, len(newer))
+	for _, entry := range newer {
+		newIDs[entry.MinCertID] = true
+	}
+
+	var changes CertificateChanges
+	for _, entry := range newer {
+		if !oldIDs[entry.MinCertID] {
+			changes.Added = append(changes.Added, entry)
+			oldIDs[entry.MinCertID] = true
+		}
+	}
+	for _, entry := range older {
+		if !newIDs[entry.MinCertID] {
+			changes.Removed = append(changes.Removed, entry)
+			newIDs[entry.MinCertID] = true
+		}
+	}
+	return changes
+}
+
+func compareWHOIS(older, newer model.WHOISInfo) []FieldChange {
+	var changes []FieldChange
+	add := func(field, oldValue, newValue string) {
+		if oldValue != newValue {
+			changes = append(changes, FieldChange{Field: field, Old: oldValue, New: newValue})
+		}
+	}
+	add("registrar", older.Registrar, newer.Registrar)
+	add("expires", formatDate(older.ExpiresAt), formatDate(newer.ExpiresAt))
+	add("name_servers", fmt.Sprint(older.NameServers), fmt.Sprint(newer.NameServers))
+	return changes
+}
+
+func webContent(r *model.DomainReport) parser.ParsedContent {
+	if r.Web.Content == nil {
+		return parser.ParsedContent{}
+	}
+	return *r.Web.Content
+}
+
+// socialProfiles flattens the per-platform links into "Platform: URL" entries
+func socialProfiles(socialMedia map[string][]string) []string {
+	var profiles []string
+	for platform, links := range socialMedia {
+		for _, link := range links {
+			profiles = append(profiles, platform+": "+link)
+		}
+	}
+	return profiles
+}
+
+func mxStrings(records []model.MXRecord) []string {
+	values := make([]string, 0, len(records))
+	for _, mx := range records {
+		values = append(values, mx.String())
+	}
+	return values
+}
+
+func toSet(values []string) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, value := range values {
+		set[value] = true
+	}
+	return set
+}
+
+func formatDate(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format("2006-01-02")
+}
@@ -0,0 +1,109 @@
+package diff
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/qepting91/gomain_analysis/internal/model"
+	"github.com/qepting91/gomain_analysis/internal/parser"
+)
+
+// scan returns a report of example.com taken at the given day of 2026 in
+// which every compared module completed
+func scan(day int) *model.DomainReport {
+	r := model.NewDomainReport("example.com")
+	r.Target.Apex = "example.com"
+	r.GeneratedAt = time.Date(2026, 1, day, 0, 0, 0, 0, time.UTC)
+	for _, name := range []string{"crt", "dns", "whois", "web"} {
+		r.Modules = append(r.Modules, model.ModuleStatus{Name: name, Status: model.StatusCompleted})
+	}
+	return r
+}
+
+func TestCompare(t *testing.T) {
+	expires := time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)
+	renewed := expires.AddDate(1, 0, 0)
+
+	older := scan(1)
+	older.CTLogs = []model.CTLog{{MinCertID: 1, NameValue: "example.com\nold.example.com"}}
+	older.DNS.ARecords = []string{"203.0.113.1", "203.0.113.2"}
+	older.DNS.MXRecords = []model.MXRecord{{Host: "mx1.example.com", Preference: 10}}
+	older.WHOIS = model.WHOISInfo{Registrar: "Example Registrar", ExpiresAt: &expires}
+	older.Web.Content = &parser.ParsedContent{Technologies: []string{"nginx"}}
+
+	newer := scan(2)
+	newer.CTLogs = []model.CTLog{
+		{MinCertID: 1, NameValue: "example.com\nold.example.com"},
+		{MinCertID: 2, NameValue: "new.example.com\n*.example.com\nother.test"},
+	}
+	newer.DNS.ARecords = []string{"203.0.113.2", "203.0.113.3"}
+	newer.DNS.MXRecords = []model.MXRecord{{Host: "mx1.example.com", Preference: 20}}
+	newer.WHOIS = model.WHOISInfo{Registrar: "Example Registrar", ExpiresAt: &renewed}
+	newer.Web.Content = &parser.ParsedContent{
+		Technologies: []string{"nginx", "WordPress"},
+		SocialMedia:  map[string][]string{"Twitter": {"https://twitter.com/example"}},
+	}
+
+	// The argument order does not matter; the later scan is the newer one
+	d, err := Compare(newer, older)
+	if err != nil {
+		t.Fatalf("Compare: %v", err)
+	}
+	if !d.OldScan.Equal(older.GeneratedAt) || !d.NewScan.Equal(newer.GeneratedAt) {
+		t.Errorf("scans = %s, %s; want oldest first", d.OldScan, d.NewScan)
+	}
+
+	tests := []struct {
+		section string
+		got     interface{}
+		want    interface{}
+	}{
+		{"new certificates", len(d.Certificates.Added), 1},
+		{"removed certificates", len(d.Certificates.Removed), 0},
+		{"subdomains", d.Subdomains, StringChanges{Added: []string{"new.example.com"}}},
+		{"A records", d.ARecords, StringChanges{Added: []string{"203.0.113.3"}, Removed: []string{"203.0.113.1"}}},
+		{"MX records", d.MXRecords, StringChanges{Added: []string{"20 mx1.example.com"}, Removed: []string{"10 mx1.example.com"}}},
+		{"WHOIS", d.WHOIS, []FieldChange{{Field: "expires", Old: "2027-01-01", New: "2028-01-01"}}},
+		{"technologies", d.Technologies, StringChanges{Added: []string{"WordPress"}}},
+		{"external links", d.ExternalLinks, StringChanges{}},
+		{"social profiles", d.SocialProfiles, StringChanges{Added: []string{"Twitter: https://twitter.com/example"}}},
+		{"not compared", len(d.NotCompared), 0},
+	}
+	for _, tt := range tests {
+		if !reflect.DeepEqual(tt.got, tt.want) {
+			t.Errorf("%s = %+v, want %+v", tt.section, tt.got, tt.want)
+		}
+	}
+	if !d.HasChanges() {
+		t.Error("HasChanges = false")
+	}
+}
+
+func TestCompareSkipsIncompleteModules(t *testing.T) {
+	older, newer := scan(1), scan(2)
+	older.DNS.ARecords = []string{"203.0.113.1"}
+	newer.Modules[1].Status = model.StatusFailed
+
+	d, err := Compare(older, newer)
+	if err != nil {
+		t.Fatalf("Compare: %v", err)
+	}
+	if !d.ARecords.Empty() {
+		t.Errorf("A records compared against a failed scan: %+v", d.ARecords)
+	}
+	if !reflect.DeepEqual(d.NotCompared, []string{"dns"}) {
+		t.Errorf("NotCompared = %v, want [dns]", d.NotCompared)
+	}
+	if d.HasChanges() {
+		t.Errorf("HasChanges = true, summary %v", d.Summary())
+	}
+}
+
+func TestCompareDifferentDomains(t *testing.T) {
+	other := scan(2)
+	other.Domain = "example.org"
+	if _, err := Compare(scan(1), other); err == nil {
+		t.Error("Compare accepted scans of different domains")
+	}
+}
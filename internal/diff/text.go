@@ -0,0 +1,63 @@
+package diff
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// WriteText prints the diff in a human readable form
+func WriteText(w io.Writer, d *Diff) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Changes for %s between %s and %s\n",
+		d.Domain, d.OldScan.Format(time.RFC3339), d.NewScan.Format(time.RFC3339))
+
+	if !d.HasChanges() {
+		fmt.Fprintln(&b, "\nNo changes detected.")
+	}
+
+	if !d.Certificates.Empty() {
+		fmt.Fprintln(&b, "\nCertificates:")
+		for _, entry := range d.Certificates.Added {
+			fmt.Fprintf(&b, "  + [%d] %s (issuer: %s, valid to %s)\n",
+				entry.MinCertID, strings.ReplaceAll(entry.NameValue, "\n", ", "), entry.IssuerName, entry.NotAfter)
+		}
+		for _, entry := range d.Certificates.Removed {
+			fmt.Fprintf(&b, "  - [%d] %s (issuer: %s, valid to %s)\n",
+				entry.MinCertID, strings.ReplaceAll(entry.NameValue, "\n", ", "), entry.IssuerName, entry.NotAfter)
+		}
+	}
+	writeStringChanges(&b, "Subdomains", d.Subdomains)
+	writeStringChanges(&b, "A records", d.ARecords)
+	writeStringChanges(&b, "MX records", d.MXRecords)
+	if len(d.WHOIS) > 0 {
+		fmt.Fprintln(&b, "\nWHOIS:")
+		for _, change := range d.WHOIS {
+			fmt.Fprintf(&b, "  ~ %s: %q -> %q\n", change.Field, change.Old, change.New)
+		}
+	}
+	writeStringChanges(&b, "Technologies", d.Technologies)
+	writeStringChanges(&b, "External links", d.ExternalLinks)
+	writeStringChanges(&b, "Social profiles", d.SocialProfiles)
+
+	if len(d.NotCompared) > 0 {
+		fmt.Fprintf(&b, "\nNot compared (module incomplete in one of the scans): %s\n", strings.Join(d.NotCompared, ", "))
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func writeStringChanges(b *strings.Builder, title string, changes StringChanges) {
+	if changes.Empty() {
+		return
+	}
+	fmt.Fprintf(b, "\n%s:\n", title)
+	for _, value := range changes.Added {
+		fmt.Fprintf(b, "  + %s\n", value)
+	}
+	for _, value := range changes.Removed {
+		fmt.Fprintf(b, "  - %s\n", value)
+	}
+}
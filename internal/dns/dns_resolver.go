@@ -3,13 +3,33 @@ package dns
 import (
 	"bufio"
 	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net"
+	"os"
 	"os/exec"
 	"strings"
+	"sync"
+	"time"
+
+	"github.com/qepting91/gomain_analysis/internal/audit"
+	"github.com/qepting91/gomain_analysis/internal/evidence"
+	"github.com/qepting91/gomain_analysis/internal/replay"
+	"github.com/qepting91/gomain_analysis/internal/scope"
+	"github.com/qepting91/gomain_analysis/internal/transport"
 )
 
+// DefaultProxyResolver answers queries sent through a proxy when no resolver
+// is configured; the system's own resolver is rarely reachable from the exit
+const DefaultProxyResolver = "1.1.1.1"
+
+// errProxied is returned by the lookups that shell out to tools which
+// cannot be made to use the proxy
+var errProxied = errors.New("not run because a proxy is configured and the tool would bypass it")
+
 type DNSResolver struct {
 	Threads     int
 	Resolver    string
@@ -23,21 +43,33 @@ func NewDNSResolver() *DNSResolver {
 	}
 }
 
-// RunAmassPassive performs passive subdomain enumeration using Amass
-func (d *DNSResolver) RunAmassPassive(domain string) ([]string, error) {
-	cmd := exec.Command("amass", "enum", "-passive", "-d", domain)
+// RunAmassPassive performs passive subdomain enumeration using Amass. It
+// refuses to run behind a proxy, since Amass would query sources directly.
+func (d *DNSResolver) RunAmassPassive(ctx context.Context, domain string) ([]string, error) {
+	if transport.Proxied() {
+		return nil, fmt.Errorf("failed to run Amass: %w", errProxied)
+	}
+	cmd := exec.CommandContext(ctx, "amass", "enum", "-passive", "-d", domain)
 	var out bytes.Buffer
 	var stderr bytes.Buffer
 	cmd.Stdout = &out
 	cmd.Stderr = &stderr
 
-	err := cmd.Run()
+	output, err := replay.Do(replay.KindExec, "amass enum -passive -d "+domain, func() ([]byte, error) {
+		entry := audit.Entry{Time: time.Now(), Kind: audit.KindExec, Command: cmd.String()}
+		err := cmd.Run()
+		audit.Record(ctx, entry, err)
+		if err != nil {
+			return nil, fmt.Errorf("%v, %s", err, stderr.String())
+		}
+		return out.Bytes(), nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to run Amass: %v, %s", err, stderr.String())
+		return nil, fmt.Errorf("failed to run Amass: %v", err)
 	}
 
 	var results []string
-	scanner := bufio.NewScanner(strings.NewReader(out.String()))
+	scanner := bufio.NewScanner(bytes.NewReader(output))
 	for scanner.Scan() {
 		if line := strings.TrimSpace(scanner.Text()); line != "" {
 			results = append(results, line)
@@ -49,26 +81,66 @@ func (d *DNSResolver) RunAmassPassive(domain string) ([]string, error) {
 }
 
 // ResolveARecords returns IPv4 addresses for a domain
-func (d *DNSResolver) ResolveARecords(domain string) ([]string, error) {
-	ips, err := net.LookupHost(domain)
+func (d *DNSResolver) ResolveARecords(ctx context.Context, domain string) ([]string, error) {
+	ips, err := replay.DoJSON(replay.KindDNS, "A "+domain, func() ([]string, error) {
+		resolver, server := d.netResolver()
+		entry := audit.Entry{Time: time.Now(), Kind: audit.KindDNS, Query: "A " + domain, Resolver: server}
+		ips, err := resolver.LookupHost(ctx, domain)
+		audit.Record(ctx, entry, err)
+		return ips, err
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to resolve A records for domain %s: %v", domain, err)
 	}
+	saveAnswer(ctx, "A "+domain, ips)
 	log.Printf("A records for domain %s: %v", domain, ips)
 	return ips, nil
 }
 
 // ResolveMXRecords returns mail servers for a domain
-func (d *DNSResolver) ResolveMXRecords(domain string) ([]*net.MX, error) {
-	mxRecords, err := net.LookupMX(domain)
+func (d *DNSResolver) ResolveMXRecords(ctx context.Context, domain string) ([]*net.MX, error) {
+	mxRecords, err := replay.DoJSON(replay.KindDNS, "MX "+domain, func() ([]*net.MX, error) {
+		resolver, server := d.netResolver()
+		entry := audit.Entry{Time: time.Now(), Kind: audit.KindDNS, Query: "MX " + domain, Resolver: server}
+		mx, err := resolver.LookupMX(ctx, domain)
+		audit.Record(ctx, entry, err)
+		return mx, err
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to resolve MX records for domain %s: %v", domain, err)
 	}
+	saveAnswer(ctx, "MX "+domain, mxRecords)
 	return mxRecords, nil
 }
 
-// ReverseLookup performs reverse DNS lookups using hakrevdns
-func (d *DNSResolver) ReverseLookup(ips []string) (map[string][]string, error) {
+// ResolveTXTRecords returns the TXT records of name. A name without any
+// is not an error: the answer is empty.
+func (d *DNSResolver) ResolveTXTRecords(ctx context.Context, name string) ([]string, error) {
+	records, err := replay.DoJSON(replay.KindDNS, "TXT "+name, func() ([]string, error) {
+		resolver, server := d.netResolver()
+		entry := audit.Entry{Time: time.Now(), Kind: audit.KindDNS, Query: "TXT " + name, Resolver: server}
+		records, err := resolver.LookupTXT(ctx, name)
+		audit.Record(ctx, entry, err)
+		var dnsErr *net.DNSError
+		if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+			return nil, nil
+		}
+		return records, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve TXT records for %s: %v", name, err)
+	}
+	saveAnswer(ctx, "TXT "+name, records)
+	return records, nil
+}
+
+// ReverseLookup performs reverse DNS lookups using hakrevdns. Behind a
+// proxy, PTR queries go through it instead, as hakrevdns cannot. Addresses
+// outside the enforced scope are skipped.
+func (d *DNSResolver) ReverseLookup(ctx context.Context, ips []string) (map[string][]string, error) {
+	if transport.Proxied() {
+		return d.reverseLookupProxied(ctx, ips)
+	}
 	results := make(map[string][]string)
 
 	args := []string{"-d"}
@@ -80,18 +152,23 @@ func (d *DNSResolver) ReverseLookup(ips []string) (map[string][]string, error) {
 	}
 
 	for _, ip := range ips {
-		cmd := exec.Command("hakrevdns", args...)
-		stdin, err := cmd.StdinPipe()
-		if err != nil {
-			return nil, err
+		if err := ctx.Err(); err != nil {
+			return results, err
+		}
+		if err := scope.Check(ip); err != nil {
+			log.Printf("Skipping reverse lookup: %v", err)
+			continue
 		}
 
-		go func() {
-			defer stdin.Close()
-			fmt.Fprintln(stdin, ip)
-		}()
-
-		output, err := cmd.Output()
+		key := "hakrevdns " + strings.Join(args, " ") + " < " + ip
+		output, err := replay.Do(replay.KindExec, key, func() ([]byte, error) {
+			cmd := exec.CommandContext(ctx, "hakrevdns", args...)
+			cmd.Stdin = strings.NewReader(ip + "\n")
+			entry := audit.Entry{Time: time.Now(), Kind: audit.KindExec, Command: key, Resolver: d.Resolver}
+			output, err := cmd.Output()
+			audit.Record(ctx, entry, err)
+			return output, err
+		})
 		if err != nil {
 			log.Printf("Error looking up IP %s: %v", ip, err)
 			continue
@@ -104,6 +181,74 @@ func (d *DNSResolver) ReverseLookup(ips []string) (map[string][]string, error) {
 	return results, nil
 }
 
+// reverseLookupProxied resolves PTR records through the proxied resolver
+func (d *DNSResolver) reverseLookupProxied(ctx context.Context, ips []string) (map[string][]string, error) {
+	results := make(map[string][]string)
+	for _, ip := range ips {
+		if err := ctx.Err(); err != nil {
+			return results, err
+		}
+		if err := scope.Check(ip); err != nil {
+			log.Printf("Skipping reverse lookup: %v", err)
+			continue
+		}
+		names, err := replay.DoJSON(replay.KindDNS, "PTR "+ip, func() ([]string, error) {
+			resolver, server := d.netResolver()
+			entry := audit.Entry{Time: time.Now(), Kind: audit.KindDNS, Query: "PTR " + ip, Resolver: server}
+			names, err := resolver.LookupAddr(ctx, ip)
+			audit.Record(ctx, entry, err)
+			return names, err
+		})
+		if err != nil {
+			log.Printf("Error looking up IP %s: %v", ip, err)
+			continue
+		}
+		for i, name := range names {
+			names[i] = strings.TrimSuffix(name, ".")
+		}
+		results[ip] = names
+	}
+	return results, nil
+}
+
+// netResolver returns the resolver to query and its address for the audit
+// log. Behind a proxy, queries go over TCP through it to the configured
+// resolver, so nothing about the target reaches the local network's DNS.
+func (d *DNSResolver) netResolver() (*net.Resolver, string) {
+	if !transport.Proxied() {
+		return net.DefaultResolver, systemResolvers()
+	}
+	server := d.Resolver
+	if server == "" {
+		server = DefaultProxyResolver
+	}
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		server = net.JoinHostPort(server, "53")
+	}
+	return &net.Resolver{
+		PreferGo: true,
+		// A stream connection makes the Go resolver use DNS over TCP
+		Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+			return transport.DialContext(ctx, "tcp", server)
+		},
+	}, server + " (tcp via proxy)"
+}
+
+// saveAnswer keeps a DNS answer as evidence when a collector is active. An
+// empty answer is saved too, since it proves a record was absent.
+func saveAnswer(ctx context.Context, query string, answer interface{}) {
+	data, err := json.MarshalIndent(map[string]interface{}{"query": query, "answer": answer}, "", "  ")
+	if err != nil {
+		log.Printf("Error encoding DNS answer for %s: %v", query, err)
+		return
+	}
+	evidence.Save(ctx, evidence.Artifact{
+		Kind:        evidence.KindDNS,
+		Source:      "dns:" + query,
+		ContentType: "application/json",
+	}, data)
+}
+
 func parseHakrevdnsOutput(output string) []string {
 	var domains []string
 	scanner := bufio.NewScanner(strings.NewReader(output))
@@ -114,3 +259,31 @@ func parseHakrevdnsOutput(output string) []string {
 	}
 	return domains
 }
+
+var (
+	resolversOnce sync.Once
+	resolvers     string
+)
+
+// systemResolvers returns the name servers the system resolver uses, as
+// listed in /etc/resolv.conf, or "system" where that file does not exist
+func systemResolvers() string {
+	resolversOnce.Do(func() {
+		resolvers = "system"
+		data, err := os.ReadFile("/etc/resolv.conf")
+		if err != nil {
+			return
+		}
+		var servers []string
+		for _, line := range strings.Split(string(data), "\n") {
+			fields := strings.Fields(line)
+			if len(fields) >= 2 && fields[0] == "nameserver" {
+				servers = append(servers, fields[1])
+			}
+		}
+		if len(servers) > 0 {
+			resolvers = strings.Join(servers, ",")
+		}
+	})
+	return resolvers
+}
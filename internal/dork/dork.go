@@ -2,17 +2,25 @@ package dork
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"log"
 	"net/http"
 	"net/url"
 	"os"
 	"strings"
+
+	"github.com/qepting91/gomain_analysis/internal/cache"
+	"github.com/qepting91/gomain_analysis/internal/model"
+	"github.com/qepting91/gomain_analysis/internal/transport"
 )
 
-// LoadDorkQueries loads Google dork queries from queries.txt
-func LoadDorkQueries() ([]string, error) {
-	file, err := os.Open("queries/queries.txt")
+// httpClient is reused for every Google query
+var httpClient = transport.NewClient(0)
+
+// LoadDorkQueries loads Google dork queries, one per line, from path
+func LoadDorkQueries(path string) ([]string, error) {
+	file, err := os.Open(path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open dork queries file: %v", err)
 	}
@@ -28,41 +36,43 @@ func LoadDorkQueries() ([]string, error) {
 		return nil, fmt.Errorf("failed to read dork queries file: %v", err)
 	}
 
-	log.Printf("Loaded %d dork queries from queries/queries.txt", len(queries))
+	log.Printf("Loaded %d dork queries from %s", len(queries), path)
 	return queries, nil
 }
 
 // PerformDorkSearch performs a Google dork search for each query
-func PerformDorkSearch(domain string, queries []string) []string {
-	client := &http.Client{}
-	var results []string
+func PerformDorkSearch(ctx context.Context, domain string, queries []string) ([]model.DorkResult, error) {
+	var results []model.DorkResult
 
 	for _, query := range queries {
+		if err := ctx.Err(); err != nil {
+			return results, err
+		}
+
 		// Replace the domain placeholder with actual domain
 		processedQuery := strings.Replace(query, "{domain}", domain, -1)
 		// URL encode the processed query
 		encodedQuery := url.QueryEscape(processedQuery)
 		searchURL := fmt.Sprintf("https://www.google.com/search?q=%s", encodedQuery)
 
-		req, err := http.NewRequest("GET", searchURL, nil)
+		req, err := http.NewRequestWithContext(cache.WithSource(ctx, cache.SourceSearch), http.MethodGet, searchURL, nil)
 		if err != nil {
-			results = append(results, fmt.Sprintf("Query: %s - Error: %v", query, err))
+			results = append(results, model.DorkResult{Query: processedQuery, Error: err.Error()})
 			continue
 		}
 
 		// Add User-Agent header to mimic browser behavior
 		req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/91.0.4472.124 Safari/537.36")
 
-		resp, err := client.Do(req)
+		resp, err := httpClient.Do(req)
 		if err != nil {
-			results = append(results, fmt.Sprintf("Query: %s - Error: %v", query, err))
+			results = append(results, model.DorkResult{Query: processedQuery, Error: err.Error()})
 			continue
 		}
-		defer resp.Body.Close()
-
-		results = append(results, fmt.Sprintf("Query: %s\nURL: %s", processedQuery, searchURL))
+		resp.Body.Close()
 
+		results = append(results, model.DorkResult{Query: processedQuery, URL: searchURL})
 	}
 
-	return results
+	return results, nil
 }
@@ -0,0 +1,345 @@
+package evidence
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/qepting91/gomain_analysis/internal/model"
+)
+
+// Kinds of artifacts
+const (
+	KindHTTP  = "http"
+	KindWHOIS = "whois"
+	KindDNS   = "dns"
+)
+
+// ManifestName is the file in the bundle listing every artifact
+const ManifestName = "manifest.json"
+
+// Artifact describes one raw answer saved in the bundle
+type Artifact struct {
+	ID     string `json:"id"`
+	Kind   string `json:"kind"`
+	Source string `json:"source"`
+	Method string `json:"method,omitempty"`
+	Status int    `json:"status,omitempty"`
+	// ContentType and ServerDate are the response headers of the same name.
+	// A cached answer keeps the date the server originally sent it.
+	ContentType string    `json:"content_type,omitempty"`
+	ServerDate  string    `json:"server_date,omitempty"`
+	RetrievedAt time.Time `json:"retrieved_at"`
+	// File is the artifact's path inside the bundle
+	File   string `json:"file"`
+	Size   int    `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// Manifest is the index of an evidence bundle
+type Manifest struct {
+	Tool      string     `json:"tool"`
+	Target    string     `json:"target"`
+	CreatedAt time.Time  `json:"created_at"`
+	Report    *Artifact  `json:"report,omitempty"`
+	Artifacts []Artifact `json:"artifacts"`
+}
+
+// Collector saves artifacts into a directory and indexes them
+type Collector struct {
+	dir       string
+	archive   string
+	mu        sync.Mutex
+	artifacts []Artifact
+}
+
+// NewCollector returns a collector writing into dir, which is created if
+// needed. An existing directory must be empty so no stale file ends up in
+// the bundle.
+func NewCollector(dir string) (*Collector, error) {
+	archive, err := archivePath(dir)
+	if err != nil {
+		return nil, err
+	}
+	if entries, err := os.ReadDir(dir); err == nil && len(entries) > 0 {
+		return nil, fmt.Errorf("evidence directory %s is not empty", dir)
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "artifacts"), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create evidence directory: %v", err)
+	}
+	return &Collector{dir: dir, archive: archive}, nil
+}
+
+// archivePath returns where the bundle in dir is packaged: <base>.zip next
+// to the directory, so "." and "evidence/" are named after the directory
+// itself rather than the path as typed
+func archivePath(dir string) (string, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve evidence directory: %v", err)
+	}
+	parent := filepath.Dir(abs)
+	if parent == abs {
+		return "", fmt.Errorf("evidence directory %s has no parent to write the archive into", dir)
+	}
+	return filepath.Join(parent, filepath.Base(abs)+".zip"), nil
+}
+
+// Archive returns the path of the zip file Finish writes
+func (c *Collector) Archive() string {
+	return c.archive
+}
+
+// Dir returns the directory the bundle is written to
+func (c *Collector) Dir() string {
+	return c.dir
+}
+
+// Len returns the number of artifacts saved so far
+func (c *Collector) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.artifacts)
+}
+
+// save writes data under the next artifact ID and indexes it
+func (c *Collector) save(a Artifact, data []byte) (string, error) {
+	sum := sha256.Sum256(data)
+	a.SHA256 = hex.EncodeToString(sum[:])
+	a.Size = len(data)
+	if a.RetrievedAt.IsZero() {
+		a.RetrievedAt = time.Now().UTC()
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	a.ID = fmt.Sprintf("E%04d", len(c.artifacts)+1)
+	a.File = path.Join("artifacts", a.ID+extension(a, data))
+	if err := os.WriteFile(filepath.Join(c.dir, filepath.FromSlash(a.File)), data, 0o644); err != nil {
+		return "", fmt.Errorf("failed to write artifact %s: %v", a.ID, err)
+	}
+	c.artifacts = append(c.artifacts, a)
+	return a.ID, nil
+}
+
+// Finish writes the report and the manifest into the bundle and packages the
+// directory as <base>.zip next to it, returning the path of the archive
+func (c *Collector) Finish(r *model.DomainReport) (string, error) {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode report: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(c.dir, "report.json"), data, 0o644); err != nil {
+		return "", fmt.Errorf("failed to write report: %v", err)
+	}
+	sum := sha256.Sum256(data)
+
+	c.mu.Lock()
+	m := Manifest{
+		Tool:      "gomain_analysis",
+		Target:    r.Domain,
+		CreatedAt: time.Now().UTC(),
+		Report: &Artifact{
+			ID:          "report",
+			Kind:        "report",
+			Source:      r.Target.Input,
+			RetrievedAt: r.GeneratedAt,
+			File:        "report.json",
+			Size:        len(data),
+			SHA256:      hex.EncodeToString(sum[:]),
+		},
+		Artifacts: append([]Artifact(nil), c.artifacts...),
+	}
+	c.mu.Unlock()
+
+	manifest, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode manifest: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(c.dir, ManifestName), manifest, 0o644); err != nil {
+		return "", fmt.Errorf("failed to write manifest: %v", err)
+	}
+
+	if err := zipDir(c.dir, c.archive); err != nil {
+		return "", err
+	}
+	return c.archive, nil
+}
+
+// zipDir packages every file under dir into the archive at dst
+func zipDir(dir, dst string) error {
+	f, err := os.Create(dst)
+	if err != nil {
+		return fmt.Errorf("failed to create evidence archive: %v", err)
+	}
+	zw := zip.NewWriter(f)
+	walkErr := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		header, err := zip.FileInfoHeader(info)
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(rel)
+		header.Method = zip.Deflate
+		w, err := zw.CreateHeader(header)
+		if err != nil {
+			return err
+		}
+		src, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+		defer src.Close()
+		_, err = io.Copy(w, src)
+		return err
+	})
+	closeErr := zw.Close()
+	if err := f.Close(); closeErr == nil {
+		closeErr = err
+	}
+	if walkErr != nil {
+		return fmt.Errorf("failed to write evidence archive: %v", walkErr)
+	}
+	if closeErr != nil {
+		return fmt.Errorf("failed to write evidence archive: %v", closeErr)
+	}
+	return nil
+}
+
+// extension picks a file extension from the content type or the data itself
+func extension(a Artifact, data []byte) string {
+	ct := strings.ToLower(a.ContentType)
+	switch {
+	case bytes.HasPrefix(bytes.TrimSpace(data), []byte("-----BEGIN")):
+		return ".pem"
+	case a.Kind == KindWHOIS, strings.HasPrefix(ct, "text/plain"):
+		return ".txt"
+	case strings.Contains(ct, "json"):
+		return ".json"
+	case strings.Contains(ct, "html"):
+		return ".html"
+	case strings.Contains(ct, "xml"):
+		return ".xml"
+	}
+	return ".bin"
+}
+
+type collectorKey struct{}
+
+type trackerKey struct{}
+
+// WithCollector makes every artifact obtained with ctx part of the bundle
+func WithCollector(ctx context.Context, c *Collector) context.Context {
+	return context.WithValue(ctx, collectorKey{}, c)
+}
+
+func collectorFrom(ctx context.Context) *Collector {
+	c, _ := ctx.Value(collectorKey{}).(*Collector)
+	return c
+}
+
+// Tracker remembers the IDs of the artifacts saved under one context, so a
+// finding can point at what it was derived from
+type Tracker struct {
+	parent *Tracker
+	mu     sync.Mutex
+	ids    []string
+}
+
+// Track returns a context whose artifacts are noted by the tracker, and by
+// any tracker further up. Without a collector it returns a nil tracker.
+func Track(ctx context.Context) (context.Context, *Tracker) {
+	if collectorFrom(ctx) == nil {
+		return ctx, nil
+	}
+	parent, _ := ctx.Value(trackerKey{}).(*Tracker)
+	t := &Tracker{parent: parent}
+	return context.WithValue(ctx, trackerKey{}, t), t
+}
+
+// IDs returns the tracked artifact IDs in the order they were saved
+func (t *Tracker) IDs() []string {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]string(nil), t.ids...)
+}
+
+// Save stores data as an artifact if ctx carries a collector, and returns
+// its ID. Failing to save evidence never fails the lookup itself.
+func Save(ctx context.Context, a Artifact, data []byte) string {
+	c := collectorFrom(ctx)
+	if c == nil {
+		return ""
+	}
+	id, err := c.save(a, data)
+	if err != nil {
+		log.Printf("Error saving evidence: %v", err)
+		return ""
+	}
+	for t, _ := ctx.Value(trackerKey{}).(*Tracker); t != nil; t = t.parent {
+		t.mu.Lock()
+		t.ids = append(t.ids, id)
+		t.mu.Unlock()
+	}
+	return id
+}
+
+type transport struct {
+	base http.RoundTripper
+}
+
+// Transport wraps base so that every response to a request made with a
+// collector in its context is saved as an artifact
+func Transport(base http.RoundTripper) http.RoundTripper {
+	return &transport{base: base}
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil || collectorFrom(req.Context()) == nil {
+		return resp, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	Save(req.Context(), Artifact{
+		Kind:        KindHTTP,
+		Source:      req.URL.String(),
+		Method:      req.Method,
+		Status:      resp.StatusCode,
+		ContentType: resp.Header.Get("Content-Type"),
+		ServerDate:  resp.Header.Get("Date"),
+	}, body)
+	return resp, nil
+}
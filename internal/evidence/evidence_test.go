@@ -0,0 +1,50 @@
+package evidence
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestArchivePath(t *testing.T) {
+	base := t.TempDir()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(base); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+	if err := os.Mkdir("case", 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir("case"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		dir  string
+		want string
+	}{
+		{".", filepath.Join(base, "case.zip")},
+		{"./", filepath.Join(base, "case.zip")},
+		{"bundle/", filepath.Join(base, "case", "bundle.zip")},
+		{"..", base + ".zip"},
+		{filepath.Join(base, "out"), filepath.Join(base, "out.zip")},
+	}
+	for _, tt := range tests {
+		got, err := archivePath(tt.dir)
+		if err != nil {
+			t.Errorf("archivePath(%q): %v", tt.dir, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("archivePath(%q) = %s, want %s", tt.dir, got, tt.want)
+		}
+	}
+
+	if got, err := archivePath(string(filepath.Separator)); err == nil {
+		t.Errorf("archivePath(root) = %s, want an error", got)
+	}
+}
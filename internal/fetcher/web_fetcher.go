@@ -1,39 +1,69 @@
 package fetcher
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"io/ioutil"
+	"io"
 	"log"
 	"net/http"
+
+	"github.com/qepting91/gomain_analysis/internal/scope"
+	"github.com/qepting91/gomain_analysis/internal/transport"
 )
 
 type WebFetcher struct {
 	client *http.Client
+	// CommonPaths are the files probed by FetchCommonFiles
+	CommonPaths []string
+}
+
+// DefaultCommonPaths lists files that often leak details about a site
+var DefaultCommonPaths = []string{
+	"/robots.txt",
+	"/.well-known/security.txt",
+	"/crossdomain.xml",
+	"/humans.txt",
+	"/.git/config",
+	"/package.json",
+	"/composer.json",
 }
 
 func NewWebFetcher() *WebFetcher {
+	client := transport.NewClient(0)
+	// Redirects are requests too, so they must stay in scope
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if len(via) >= 10 {
+			return errors.New("stopped after 10 redirects")
+		}
+		return scope.Check(req.URL.Hostname())
+	}
 	return &WebFetcher{
-		client: &http.Client{},
+		client:      client,
+		CommonPaths: DefaultCommonPaths,
 	}
 }
 
-// FetchRobotsTxt retrieves robots.txt content
-func (w *WebFetcher) FetchRobotsTxt(domain string) (string, error) {
-	url := fmt.Sprintf("https://%s/robots.txt", domain)
-	return w.FetchWebContent(url)
-}
-
 // FetchSitemap retrieves sitemap content
-func (w *WebFetcher) FetchSitemap(domain string) (string, error) {
+func (w *WebFetcher) FetchSitemap(ctx context.Context, domain string) (string, error) {
 	url := fmt.Sprintf("https://%s/sitemap.xml", domain)
-	return w.FetchWebContent(url)
+	return w.FetchWebContent(ctx, url)
 }
 
-// FetchWebContent retrieves content from any URL
-func (w *WebFetcher) FetchWebContent(url string) (string, error) {
-	resp, err := w.client.Get(url)
+// FetchWebContent retrieves content from any URL in scope. The returned
+// error matches scope.ErrOutOfScope when the URL, or a redirect, is not.
+func (w *WebFetcher) FetchWebContent(ctx context.Context, url string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
-		return "", fmt.Errorf("failed to fetch content from %s: %v", url, err)
+		return "", fmt.Errorf("failed to create request for %s: %v", url, err)
+	}
+	if err := scope.Check(req.URL.Hostname()); err != nil {
+		return "", err
+	}
+	resp, err := w.client.Do(req)
+	if err != nil {
+		// Wrapped so a redirect out of scope can be told apart
+		return "", fmt.Errorf("failed to fetch content from %s: %w", url, err)
 	}
 	defer resp.Body.Close()
 
@@ -41,7 +71,7 @@ func (w *WebFetcher) FetchWebContent(url string) (string, error) {
 		return "", fmt.Errorf("failed to fetch content from %s, status code: %d", url, resp.StatusCode)
 	}
 
-	body, err := ioutil.ReadAll(resp.Body)
+	body, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return "", fmt.Errorf("failed to read response body from %s: %v", url, err)
 	}
@@ -51,20 +81,15 @@ func (w *WebFetcher) FetchWebContent(url string) (string, error) {
 }
 
 // FetchCommonFiles attempts to fetch common files that might contain domain info
-func (w *WebFetcher) FetchCommonFiles(domain string) map[string]string {
-	commonPaths := []string{
-		"/.well-known/security.txt",
-		"/crossdomain.xml",
-		"/humans.txt",
-		"/.git/config",
-		"/package.json",
-		"/composer.json",
-	}
-
+func (w *WebFetcher) FetchCommonFiles(ctx context.Context, domain string) map[string]string {
 	results := make(map[string]string)
-	for _, path := range commonPaths {
+	if err := scope.Check(domain); err != nil {
+		log.Printf("Not fetching common files: %v", err)
+		return results
+	}
+	for _, path := range w.CommonPaths {
 		url := fmt.Sprintf("https://%s%s", domain, path)
-		content, err := w.FetchWebContent(url)
+		content, err := w.FetchWebContent(ctx, url)
 		if err == nil {
 			results[path] = content
 		}
@@ -0,0 +1,85 @@
+package findings
+
+import (
+	"sort"
+	"time"
+
+	"github.com/qepting91/gomain_analysis/internal/model"
+)
+
+// Hit is one place where a rule matched
+type Hit struct {
+	// Subject is what the finding is about, e.g. a host, URL or certificate
+	Subject string
+	// Evidence says what in the report triggered the rule
+	Evidence string
+	// Artifacts are the evidence bundle IDs of the data the hit came from
+	Artifacts []string
+}
+
+// Rule turns a pattern in the collected data into findings. The severity,
+// title and remediation are fixed; Match only decides where the rule applies.
+type Rule struct {
+	ID          string
+	Title       string
+	Severity    string
+	Remediation string
+	// Requires names the modules that must have completed for the rule to
+	// be evaluated, so that missing data is never mistaken for a problem
+	Requires []string
+	// Match returns the hits in r, judged as of now
+	Match func(r *model.DomainReport, now time.Time) []Hit
+}
+
+// Evaluate runs every rule against r and returns the findings, most severe
+// first and otherwise in rule order
+func Evaluate(r *model.DomainReport, now time.Time) []model.Finding {
+	var findings []model.Finding
+	for _, rule := range Rules {
+		if !ready(rule, r) {
+			continue
+		}
+		for _, hit := range rule.Match(r, now) {
+			findings = append(findings, model.Finding{
+				Rule:        rule.ID,
+				Title:       rule.Title,
+				Severity:    rule.Severity,
+				Subject:     hit.Subject,
+				Evidence:    hit.Evidence,
+				Artifacts:   hit.Artifacts,
+				Remediation: rule.Remediation,
+			})
+		}
+	}
+	sort.SliceStable(findings, func(i, j int) bool {
+		return Rank(findings[i].Severity) > Rank(findings[j].Severity)
+	})
+	return findings
+}
+
+// ready reports whether every module the rule needs has completed
+func ready(rule Rule, r *model.DomainReport) bool {
+	for _, name := range rule.Requires {
+		if !r.ModuleCompleted(name) {
+			return false
+		}
+	}
+	return true
+}
+
+// Rank orders severities from info (1) to critical (5); unknown ones are 0
+func Rank(severity string) int {
+	switch severity {
+	case model.SeverityInfo:
+		return 1
+	case model.SeverityLow:
+		return 2
+	case model.SeverityMedium:
+		return 3
+	case model.SeverityHigh:
+		return 4
+	case model.SeverityCritical:
+		return 5
+	}
+	return 0
+}
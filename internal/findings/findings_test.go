@@ -0,0 +1,209 @@
+package findings
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/qepting91/gomain_analysis/internal/model"
+	"github.com/qepting91/gomain_analysis/internal/parser"
+)
+
+var now = time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+// completed returns a report for example.com on which the named modules ran
+func completed(modules ...string) *model.DomainReport {
+	r := model.NewDomainReport("www.example.com")
+	r.Target = model.Target{Input: "www.example.com", Host: "www.example.com", Apex: "example.com"}
+	for _, name := range modules {
+		r.Modules = append(r.Modules, model.ModuleStatus{Name: name, Status: model.StatusCompleted})
+	}
+	return r
+}
+
+func days(n int) time.Time {
+	return now.Add(time.Duration(n) * 24 * time.Hour)
+}
+
+func TestRules(t *testing.T) {
+	tests := []struct {
+		name      string
+		report    func() *model.DomainReport
+		rule      string
+		subjects  []string
+		artifacts []string
+	}{
+		{
+			name: "certificate expiring",
+			report: func() *model.DomainReport {
+				r := completed("crt")
+				r.Certificates = []model.Certificate{{
+					SerialNumber: "01", Issuer: "Test CA", DNSNames: []string{"www.example.com"},
+					NotBefore: days(-60), NotAfter: days(10), Evidence: []string{"cert-01"},
+				}}
+				return r
+			},
+			rule:      "cert-expiring",
+			subjects:  []string{"www.example.com"},
+			artifacts: []string{"cert-01"},
+		},
+		{
+			name: "certificate already renewed",
+			report: func() *model.DomainReport {
+				r := completed("crt")
+				r.Certificates = []model.Certificate{
+					{SerialNumber: "01", DNSNames: []string{"www.example.com"}, NotBefore: days(-60), NotAfter: days(10)},
+					{SerialNumber: "02", DNSNames: []string{"www.example.com", "example.com"}, NotBefore: days(-1), NotAfter: days(89)},
+				}
+				return r
+			},
+			rule: "cert-expiring",
+		},
+		{
+			name: "certificate already expired",
+			report: func() *model.DomainReport {
+				r := completed("crt")
+				r.Certificates = []model.Certificate{{SerialNumber: "01", NotBefore: days(-90), NotAfter: days(-1)}}
+				return r
+			},
+			rule: "cert-expiring",
+		},
+		{
+			name: "git config exposed",
+			report: func() *model.DomainReport {
+				r := completed("files")
+				r.Files = []model.CommonFile{{
+					Path: "/.git/config", URL: "https://www.example.com/.git/config",
+					Content: "[core]\n\trepositoryformatversion = 0\n", Evidence: []string{"http-1"},
+				}}
+				return r
+			},
+			rule:      "git-config-exposed",
+			subjects:  []string{"https://www.example.com/.git/config"},
+			artifacts: []string{"http-1"},
+		},
+		{
+			name: "git config path serves a catch-all page",
+			report: func() *model.DomainReport {
+				r := completed("files")
+				r.Files = []model.CommonFile{{Path: "/.git/config", Content: "<html>Not found</html>"}}
+				return r
+			},
+			rule: "git-config-exposed",
+		},
+		{
+			name: "form posts over http",
+			report: func() *model.DomainReport {
+				r := completed("web")
+				r.Web = model.WebInfo{
+					URL:      "http://www.example.com/login",
+					Content:  &parser.ParsedContent{Forms: []string{"/session", "https://www.example.com/search", "/session"}},
+					Evidence: []string{"http-2"},
+				}
+				return r
+			},
+			rule:      "form-insecure-action",
+			subjects:  []string{"http://www.example.com/session"},
+			artifacts: []string{"http-2"},
+		},
+		{
+			name: "registration expiring",
+			report: func() *model.DomainReport {
+				r := completed("whois")
+				expires := days(20)
+				r.WHOIS = model.WHOISInfo{ExpiresAt: &expires, Evidence: []string{"whois-1"}}
+				return r
+			},
+			rule:      "whois-expiring",
+			subjects:  []string{"example.com"},
+			artifacts: []string{"whois-1"},
+		},
+		{
+			name: "registration far from expiry",
+			report: func() *model.DomainReport {
+				r := completed("whois")
+				expires := days(365)
+				r.WHOIS = model.WHOISInfo{ExpiresAt: &expires}
+				return r
+			},
+			rule: "whois-expiring",
+		},
+		{
+			name: "comment mentions a password",
+			report: func() *model.DomainReport {
+				r := completed("web")
+				r.Web = model.WebInfo{
+					URL:      "https://www.example.com/",
+					Content:  &parser.ParsedContent{Comments: []string{"TODO: remove", "admin Password is hunter2"}},
+					Evidence: []string{"http-3"},
+				}
+				return r
+			},
+			rule:      "comment-password",
+			subjects:  []string{"https://www.example.com/"},
+			artifacts: []string{"http-3"},
+		},
+		{
+			name: "dmarc missing",
+			report: func() *model.DomainReport {
+				r := completed("dns")
+				r.DNS.DMARC = &model.DMARCInfo{Domain: "example.com", Evidence: []string{"dns-1", "dns-2"}}
+				return r
+			},
+			rule:      "dmarc-missing",
+			subjects:  []string{"example.com"},
+			artifacts: []string{"dns-1", "dns-2"},
+		},
+		{
+			name: "dmarc published",
+			report: func() *model.DomainReport {
+				r := completed("dns")
+				r.DNS.DMARC = &model.DMARCInfo{Domain: "example.com", Record: "v=DMARC1; p=reject"}
+				return r
+			},
+			rule: "dmarc-missing",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var subjects, artifacts []string
+			for _, f := range Evaluate(tt.report(), now) {
+				if f.Rule != tt.rule {
+					t.Errorf("unexpected finding %s for %s", f.Rule, f.Subject)
+					continue
+				}
+				subjects = append(subjects, f.Subject)
+				artifacts = append(artifacts, f.Artifacts...)
+			}
+			if got, want := strings.Join(subjects, " "), strings.Join(tt.subjects, " "); got != want {
+				t.Errorf("subjects = %q, want %q", got, want)
+			}
+			if got, want := strings.Join(artifacts, " "), strings.Join(tt.artifacts, " "); got != want {
+				t.Errorf("artifacts = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestEvaluateRequiresCompletedModules(t *testing.T) {
+	r := completed()
+	r.DNS.DMARC = &model.DMARCInfo{Domain: "example.com"}
+	r.Modules = []model.ModuleStatus{{Name: "dns", Status: model.StatusFailed}}
+	if got := Evaluate(r, now); len(got) != 0 {
+		t.Errorf("rules ran on a failed module: %v", got)
+	}
+}
+
+func TestEvaluateOrdersBySeverity(t *testing.T) {
+	r := completed("dns", "files")
+	r.DNS.DMARC = &model.DMARCInfo{Domain: "example.com"}
+	r.Files = []model.CommonFile{{Path: "/.git/config", Content: "[core]"}}
+
+	got := Evaluate(r, now)
+	if len(got) != 2 {
+		t.Fatalf("got %d findings, want 2", len(got))
+	}
+	if got[0].Rule != "git-config-exposed" || got[1].Rule != "dmarc-missing" {
+		t.Errorf("order = %s, %s; want the high severity finding first", got[0].Rule, got[1].Rule)
+	}
+}
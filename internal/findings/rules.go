@@ -0,0 +1,241 @@
+package findings
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/qepting91/gomain_analysis/internal/model"
+)
+
+// ExpiryWindow is how close an expiry date has to be to raise a finding
+const ExpiryWindow = 30 * 24 * time.Hour
+
+// Rules are evaluated in order by Evaluate
+var Rules = []Rule{
+	{
+		ID:          "cert-expiring",
+		Title:       "TLS certificate expiring soon",
+		Severity:    model.SeverityMedium,
+		Remediation: "Renew the certificate, or check that automated renewal is working, before it expires.",
+		Requires:    []string{"crt"},
+		Match:       expiringCertificates,
+	},
+	{
+		ID:          "git-config-exposed",
+		Title:       "Git repository exposed",
+		Severity:    model.SeverityHigh,
+		Remediation: "Block access to /.git on the web server and remove the repository from the document root; rotate any credentials it contained.",
+		Requires:    []string{"files"},
+		Match:       exposedGitConfig,
+	},
+	{
+		ID:          "form-insecure-action",
+		Title:       "Form submits over plain HTTP",
+		Severity:    model.SeverityMedium,
+		Remediation: "Point the form action at an https:// URL so submitted data is encrypted in transit.",
+		Requires:    []string{"web"},
+		Match:       insecureForms,
+	},
+	{
+		ID:          "whois-expiring",
+		Title:       "Domain registration expiring soon",
+		Severity:    model.SeverityHigh,
+		Remediation: "Renew the domain with the registrar and enable auto-renewal to avoid losing it.",
+		Requires:    []string{"whois"},
+		Match:       expiringRegistration,
+	},
+	{
+		ID:          "comment-password",
+		Title:       "HTML comment mentions a password",
+		Severity:    model.SeverityMedium,
+		Remediation: "Remove the comment from the page source and change the password if one was disclosed.",
+		Requires:    []string{"web"},
+		Match:       passwordComments,
+	},
+	{
+		ID:          "dmarc-missing",
+		Title:       "No DMARC policy",
+		Severity:    model.SeverityMedium,
+		Remediation: "Publish a DMARC record at _dmarc.<domain>, starting with p=none and moving to quarantine or reject.",
+		Requires:    []string{"dns"},
+		Match:       missingDMARC,
+	},
+}
+
+// expiringCertificates flags currently valid certificates that expire within
+// the window, unless a later certificate for the same names is already issued
+func expiringCertificates(r *model.DomainReport, now time.Time) []Hit {
+	deadline := now.Add(ExpiryWindow)
+	var valid []model.Certificate
+	for _, cert := range r.Certificates {
+		if !cert.NotBefore.After(now) && cert.NotAfter.After(now) {
+			valid = append(valid, cert)
+		}
+	}
+
+	var hits []Hit
+	seen := make(map[string]bool)
+	for _, cert := range valid {
+		if cert.NotAfter.After(deadline) || seen[cert.SerialNumber] {
+			continue
+		}
+		seen[cert.SerialNumber] = true
+		if renewed(cert, valid, deadline) {
+			continue
+		}
+		days := int(cert.NotAfter.Sub(now).Hours() / 24)
+		hits = append(hits, Hit{
+			Subject: certName(cert),
+			Evidence: fmt.Sprintf("certificate %s issued by %s expires on %s (in %d days)",
+				cert.SerialNumber, cert.Issuer, cert.NotAfter.Format("2006-01-02"), days),
+			Artifacts: cert.Evidence,
+		})
+	}
+	return hits
+}
+
+// renewed reports whether another valid certificate covers every name of
+// cert and lasts beyond the deadline
+func renewed(cert model.Certificate, valid []model.Certificate, deadline time.Time) bool {
+	for _, other := range valid {
+		if !other.NotAfter.After(deadline) || len(cert.DNSNames) == 0 && other.Subject != cert.Subject {
+			continue
+		}
+		covered := true
+		for _, name := range cert.DNSNames {
+			if !contains(other.DNSNames, name) {
+				covered = false
+				break
+			}
+		}
+		if covered {
+			return true
+		}
+	}
+	return false
+}
+
+func certName(cert model.Certificate) string {
+	if len(cert.DNSNames) > 0 {
+		return strings.Join(cert.DNSNames, ", ")
+	}
+	return cert.Subject
+}
+
+// exposedGitConfig flags a /.git/config that really is a Git configuration,
+// not a catch-all page served for every path
+func exposedGitConfig(r *model.DomainReport, _ time.Time) []Hit {
+	for _, file := range r.Files {
+		if file.Path == "/.git/config" && strings.Contains(file.Content, "[core]") {
+			return []Hit{{
+				Subject:   file.URL,
+				Evidence:  fmt.Sprintf("%s returned a Git configuration (%d bytes)", file.Path, file.Size),
+				Artifacts: file.Evidence,
+			}}
+		}
+	}
+	return nil
+}
+
+// insecureForms flags forms whose action, resolved against the page, is an
+// http:// URL
+func insecureForms(r *model.DomainReport, _ time.Time) []Hit {
+	if r.Web.Content == nil {
+		return nil
+	}
+	page, err := url.Parse(r.Web.URL)
+	if err != nil {
+		return nil
+	}
+	var hits []Hit
+	seen := make(map[string]bool)
+	for _, action := range r.Web.Content.Forms {
+		ref, err := url.Parse(strings.TrimSpace(action))
+		if err != nil {
+			continue
+		}
+		target := page.ResolveReference(ref)
+		if target.Scheme != "http" || seen[target.String()] {
+			continue
+		}
+		seen[target.String()] = true
+		hits = append(hits, Hit{
+			Subject:   target.String(),
+			Evidence:  fmt.Sprintf("form on %s has action %q", r.Web.URL, action),
+			Artifacts: r.Web.Evidence,
+		})
+	}
+	return hits
+}
+
+// expiringRegistration flags a domain whose registration has expired or
+// expires within the window
+func expiringRegistration(r *model.DomainReport, now time.Time) []Hit {
+	expires := r.WHOIS.ExpiresAt
+	if expires == nil || expires.After(now.Add(ExpiryWindow)) {
+		return nil
+	}
+	evidence := fmt.Sprintf("registration expires on %s (in %d days)",
+		expires.Format("2006-01-02"), int(expires.Sub(now).Hours()/24))
+	if !expires.After(now) {
+		evidence = fmt.Sprintf("registration expired on %s", expires.Format("2006-01-02"))
+	}
+	return []Hit{{Subject: apex(r), Evidence: evidence, Artifacts: r.WHOIS.Evidence}}
+}
+
+// maxCommentEvidence caps how much of a comment is quoted in a finding
+const maxCommentEvidence = 200
+
+// passwordComments flags HTML comments that mention a password
+func passwordComments(r *model.DomainReport, _ time.Time) []Hit {
+	if r.Web.Content == nil {
+		return nil
+	}
+	var hits []Hit
+	for _, comment := range r.Web.Content.Comments {
+		if !strings.Contains(strings.ToLower(comment), "password") {
+			continue
+		}
+		if runes := []rune(comment); len(runes) > maxCommentEvidence {
+			comment = string(runes[:maxCommentEvidence]) + "..."
+		}
+		hits = append(hits, Hit{
+			Subject:   r.Web.URL,
+			Evidence:  fmt.Sprintf("<!-- %s -->", comment),
+			Artifacts: r.Web.Evidence,
+		})
+	}
+	return hits
+}
+
+// missingDMARC flags a host for which no DMARC policy is published
+func missingDMARC(r *model.DomainReport, _ time.Time) []Hit {
+	dmarc := r.DNS.DMARC
+	if dmarc == nil || dmarc.Record != "" {
+		return nil
+	}
+	return []Hit{{
+		Subject:   apex(r),
+		Evidence:  fmt.Sprintf("no v=DMARC1 TXT record at _dmarc.%s", dmarc.Domain),
+		Artifacts: dmarc.Evidence,
+	}}
+}
+
+// apex returns the registrable domain, or the host when there is none
+func apex(r *model.DomainReport) string {
+	if r.Target.Apex != "" {
+		return r.Target.Apex
+	}
+	return r.Domain
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"github.com/qepting91/gomain_analysis/internal/config"
+	"github.com/qepting91/gomain_analysis/internal/model"
 
 	"github.com/oschwald/geoip2-golang"
 )
@@ -31,33 +32,57 @@ func LookupGeolocation(ip string) (*geoip2.City, error) {
 	return record, nil
 }
 
-// FormatGeoLocation formats the City record into a readable string
-func FormatGeoLocation(record *geoip2.City) string {
+// NewGeoLocation converts a City record into a report entry
+func NewGeoLocation(ip string, record *geoip2.City) model.GeoLocation {
+	loc := model.GeoLocation{
+		IP:             ip,
+		City:           record.City.Names["en"],
+		Country:        record.Country.Names["en"],
+		CountryCode:    record.Country.IsoCode,
+		Continent:      record.Continent.Names["en"],
+		ContinentCode:  record.Continent.Code,
+		Latitude:       record.Location.Latitude,
+		Longitude:      record.Location.Longitude,
+		TimeZone:       record.Location.TimeZone,
+		AccuracyRadius: record.Location.AccuracyRadius,
+		PostalCode:     record.Postal.Code,
+		AnonymousProxy: record.Traits.IsAnonymousProxy,
+		Satellite:      record.Traits.IsSatelliteProvider,
+		Anycast:        record.Traits.IsAnycast,
+	}
+	if len(record.Subdivisions) > 0 {
+		loc.Region = record.Subdivisions[0].Names["en"]
+	}
+	return loc
+}
+
+// FormatGeoLocation formats a geolocation entry into a readable string
+func FormatGeoLocation(loc model.GeoLocation) string {
 	var info strings.Builder
 
 	// Location Information
-	fmt.Fprintf(&info, "City: %s\n", record.City.Names["en"])
-	if len(record.Subdivisions) > 0 {
-		fmt.Fprintf(&info, "Region: %s\n", record.Subdivisions[0].Names["en"])
+	fmt.Fprintf(&info, "City: %s\n", loc.City)
+	if loc.Region != "" {
+		fmt.Fprintf(&info, "Region: %s\n", loc.Region)
 	}
-	fmt.Fprintf(&info, "Country: %s (%s)\n", record.Country.Names["en"], record.Country.IsoCode)
-	fmt.Fprintf(&info, "Continent: %s (%s)\n", record.Continent.Names["en"], record.Continent.Code)
+	fmt.Fprintf(&info, "Country: %s (%s)\n", loc.Country, loc.CountryCode)
+	fmt.Fprintf(&info, "Continent: %s (%s)\n", loc.Continent, loc.ContinentCode)
 
 	// Geographical Coordinates
-	fmt.Fprintf(&info, "Coordinates: %.4f, %.4f\n", record.Location.Latitude, record.Location.Longitude)
-	fmt.Fprintf(&info, "Timezone: %s\n", record.Location.TimeZone)
+	fmt.Fprintf(&info, "Coordinates: %.4f, %.4f\n", loc.Latitude, loc.Longitude)
+	fmt.Fprintf(&info, "Timezone: %s\n", loc.TimeZone)
 
 	// Additional Details
-	fmt.Fprintf(&info, "Accuracy Radius: %d km\n", record.Location.AccuracyRadius)
-	if record.Postal.Code != "" {
-		fmt.Fprintf(&info, "Postal Code: %s\n", record.Postal.Code)
+	fmt.Fprintf(&info, "Accuracy Radius: %d km\n", loc.AccuracyRadius)
+	if loc.PostalCode != "" {
+		fmt.Fprintf(&info, "Postal Code: %s\n", loc.PostalCode)
 	}
 
 	// Network Traits
 	fmt.Fprintf(&info, "Network Traits:\n")
-	fmt.Fprintf(&info, "- Anonymous Proxy: %t\n", record.Traits.IsAnonymousProxy)
-	fmt.Fprintf(&info, "- Satellite Provider: %t\n", record.Traits.IsSatelliteProvider)
-	fmt.Fprintf(&info, "- Anycast: %t\n", record.Traits.IsAnycast)
+	fmt.Fprintf(&info, "- Anonymous Proxy: %t\n", loc.AnonymousProxy)
+	fmt.Fprintf(&info, "- Satellite Provider: %t\n", loc.Satellite)
+	fmt.Fprintf(&info, "- Anycast: %t\n", loc.Anycast)
 
 	return info.String()
 }
@@ -0,0 +1,148 @@
+package graph
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WriteGraphML writes the graph as GraphML, readable by Gephi, yEd and
+// Cytoscape. Every node property becomes a declared string attribute.
+func WriteGraphML(w io.Writer, g *Graph) error {
+	bw := bufio.NewWriter(w)
+	keys := g.PropKeys()
+
+	fmt.Fprintln(bw, `<?xml version="1.0" encoding="UTF-8"?>`)
+	fmt.Fprintln(bw, `<graphml xmlns="http://graphml.graphdrawing.org/xmlns">`)
+	fmt.Fprintln(bw, `  <key id="type" for="all" attr.name="type" attr.type="string"/>`)
+	fmt.Fprintln(bw, `  <key id="label" for="node" attr.name="label" attr.type="string"/>`)
+	for _, k := range keys {
+		fmt.Fprintf(bw, "  <key id=\"p_%s\" for=\"node\" attr.name=\"%s\" attr.type=\"string\"/>\n", xmlEscape(k), xmlEscape(k))
+	}
+	fmt.Fprintf(bw, "  <graph id=\"%s\" edgedefault=\"directed\">\n", xmlEscape(g.Name))
+	for _, n := range g.Nodes {
+		fmt.Fprintf(bw, "    <node id=\"%s\">\n", xmlEscape(n.ID))
+		fmt.Fprintf(bw, "      <data key=\"type\">%s</data>\n", xmlEscape(n.Type))
+		fmt.Fprintf(bw, "      <data key=\"label\">%s</data>\n", xmlEscape(n.Label))
+		for _, k := range keys {
+			if v, ok := n.Props[k]; ok {
+				fmt.Fprintf(bw, "      <data key=\"p_%s\">%s</data>\n", xmlEscape(k), xmlEscape(v))
+			}
+		}
+		fmt.Fprintln(bw, "    </node>")
+	}
+	for i, e := range g.Edges {
+		fmt.Fprintf(bw, "    <edge id=\"e%d\" source=\"%s\" target=\"%s\">\n", i, xmlEscape(e.Source), xmlEscape(e.Target))
+		fmt.Fprintf(bw, "      <data key=\"type\">%s</data>\n", xmlEscape(e.Type))
+		fmt.Fprintln(bw, "    </edge>")
+	}
+	fmt.Fprintln(bw, "  </graph>")
+	fmt.Fprintln(bw, "</graphml>")
+
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("failed to write GraphML: %v", err)
+	}
+	return nil
+}
+
+func xmlEscape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+// dotShapes distinguishes the node types when rendered with Graphviz
+var dotShapes = map[string]string{
+	NodeDomain:      "doubleoctagon",
+	NodeHostname:    "box",
+	NodeIP:          "ellipse",
+	NodeCertificate: "note",
+	NodeIssuer:      "house",
+	NodeRegistrar:   "house",
+	NodePage:        "component",
+	NodeSocial:      "cds",
+	NodeEmail:       "tab",
+	NodePhone:       "tab",
+	NodeFinding:     "octagon",
+}
+
+// WriteDOT writes the graph in Graphviz DOT syntax
+func WriteDOT(w io.Writer, g *Graph) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "digraph %s {\n", dotQuote(g.Name))
+	fmt.Fprintln(bw, "  rankdir=LR;")
+	fmt.Fprintln(bw, `  node [fontname="Helvetica", fontsize=10];`)
+	fmt.Fprintln(bw, `  edge [fontname="Helvetica", fontsize=8];`)
+	for _, n := range g.Nodes {
+		shape := dotShapes[n.Type]
+		if shape == "" {
+			shape = "box"
+		}
+		fmt.Fprintf(bw, "  %s [label=%s, shape=%s, type=%s];\n",
+			dotQuote(n.ID), dotQuote(n.Label), shape, dotQuote(n.Type))
+	}
+	for _, e := range g.Edges {
+		fmt.Fprintf(bw, "  %s -> %s [label=%s];\n", dotQuote(e.Source), dotQuote(e.Target), dotQuote(e.Type))
+	}
+	fmt.Fprintln(bw, "}")
+
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("failed to write DOT graph: %v", err)
+	}
+	return nil
+}
+
+func dotQuote(s string) string {
+	s = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", "").Replace(s)
+	return `"` + s + `"`
+}
+
+// WriteNeo4jCSV writes a zip archive holding nodes.csv and relationships.csv
+// with the headers expected by neo4j-admin database import:
+//
+//	neo4j-admin database import full --nodes=nodes.csv --relationships=relationships.csv
+func WriteNeo4jCSV(w io.Writer, g *Graph) error {
+	zw := zip.NewWriter(w)
+
+	f, err := zw.Create("nodes.csv")
+	if err != nil {
+		return fmt.Errorf("failed to create nodes.csv: %v", err)
+	}
+	keys := g.PropKeys()
+	cw := csv.NewWriter(f)
+	cw.Write(append([]string{"id:ID", ":LABEL", "name"}, keys...))
+	for _, n := range g.Nodes {
+		row := []string{n.ID, n.Type, n.Label}
+		for _, k := range keys {
+			row = append(row, n.Props[k])
+		}
+		cw.Write(row)
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return fmt.Errorf("failed to write nodes.csv: %v", err)
+	}
+
+	f, err = zw.Create("relationships.csv")
+	if err != nil {
+		return fmt.Errorf("failed to create relationships.csv: %v", err)
+	}
+	cw = csv.NewWriter(f)
+	cw.Write([]string{":START_ID", ":END_ID", ":TYPE"})
+	for _, e := range g.Edges {
+		cw.Write([]string{e.Source, e.Target, strings.ToUpper(e.Type)})
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return fmt.Errorf("failed to write relationships.csv: %v", err)
+	}
+
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("failed to write Neo4j archive: %v", err)
+	}
+	return nil
+}
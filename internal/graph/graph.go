@@ -0,0 +1,279 @@
+package graph
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/qepting91/gomain_analysis/internal/model"
+)
+
+// Node types
+const (
+	NodeDomain      = "Domain"
+	NodeHostname    = "Hostname"
+	NodeIP          = "IPAddress"
+	NodeCertificate = "Certificate"
+	NodeIssuer      = "Issuer"
+	NodeRegistrar   = "Registrar"
+	NodePage        = "WebPage"
+	NodeSocial      = "SocialProfile"
+	NodeEmail       = "Email"
+	NodePhone       = "Phone"
+	NodeFinding     = "Finding"
+)
+
+// Edge types
+const (
+	EdgeResolvesTo   = "resolves_to"
+	EdgePTR          = "ptr"
+	EdgeMX           = "mx"
+	EdgeNameServer   = "name_server"
+	EdgeSubdomain    = "has_subdomain"
+	EdgeCovers       = "covers"
+	EdgeIssuedBy     = "issued_by"
+	EdgeRegisteredAt = "registered_with"
+	EdgeHosts        = "hosts"
+	EdgeLinksTo      = "links_to"
+	EdgeMentions     = "mentions"
+	EdgeHasFinding   = "has_finding"
+)
+
+// Node is an entity found during the scan
+type Node struct {
+	ID    string
+	Type  string
+	Label string
+	Props map[string]string
+}
+
+// Edge is a directed, typed relationship between two nodes
+type Edge struct {
+	Source string
+	Target string
+	Type   string
+}
+
+// Graph holds the nodes and edges of one report in the order they were found
+type Graph struct {
+	Name  string
+	Nodes []*Node
+	Edges []Edge
+
+	byID  map[string]*Node
+	edges map[Edge]bool
+}
+
+func newGraph(name string) *Graph {
+	return &Graph{Name: name, byID: make(map[string]*Node), edges: make(map[Edge]bool)}
+}
+
+// node returns the node with the given type and key, adding it on first use.
+// All DNS names share one namespace so a host seen as a SAN, an MX and a PTR
+// name is a single node.
+func (g *Graph) node(typ, key, label string) *Node {
+	id := namespace(typ) + ":" + key
+	if n, ok := g.byID[id]; ok {
+		return n
+	}
+	n := &Node{ID: id, Type: typ, Label: label, Props: make(map[string]string)}
+	g.byID[id] = n
+	g.Nodes = append(g.Nodes, n)
+	return n
+}
+
+func namespace(typ string) string {
+	switch typ {
+	case NodeDomain, NodeHostname:
+		return "dns"
+	case NodeIP:
+		return "ip"
+	case NodeCertificate:
+		return "cert"
+	case NodeIssuer:
+		return "issuer"
+	case NodeRegistrar:
+		return "registrar"
+	case NodePage:
+		return "url"
+	case NodeSocial:
+		return "social"
+	case NodeEmail:
+		return "email"
+	case NodeFinding:
+		return "finding"
+	default:
+		return "phone"
+	}
+}
+
+func (g *Graph) host(name string) *Node {
+	name = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(name), "."))
+	return g.node(NodeHostname, name, name)
+}
+
+func (g *Graph) link(from *Node, typ string, to *Node) {
+	e := Edge{Source: from.ID, Target: to.ID, Type: typ}
+	if from.ID == to.ID || g.edges[e] {
+		return
+	}
+	g.edges[e] = true
+	g.Edges = append(g.Edges, e)
+}
+
+// PropKeys returns every property name used by the nodes, sorted
+func (g *Graph) PropKeys() []string {
+	seen := make(map[string]bool)
+	var keys []string
+	for _, n := range g.Nodes {
+		for k := range n.Props {
+			if !seen[k] {
+				seen[k] = true
+				keys = append(keys, k)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Build turns a report into an entity graph. Pivot reports are merged into
+// the same graph, so a host found in one scan and analyzed in another is a
+// single node.
+func Build(r *model.DomainReport) *Graph {
+	g := newGraph(r.Domain)
+	g.addReport(r)
+	return g
+}
+
+func (g *Graph) addReport(r *model.DomainReport) {
+	rootType := NodeDomain
+	if r.Target.IsIP {
+		rootType = NodeIP
+	}
+	root := g.node(rootType, r.Domain, r.Domain)
+	apex := root
+	if r.Target.Apex != "" && r.Target.Apex != r.Domain {
+		apex = g.node(NodeDomain, r.Target.Apex, r.Target.Apex)
+		g.link(apex, EdgeSubdomain, root)
+	}
+	for _, name := range r.Subdomains() {
+		g.link(apex, EdgeSubdomain, g.host(name))
+	}
+
+	for _, ip := range r.DNS.ARecords {
+		g.link(root, EdgeResolvesTo, g.node(NodeIP, ip, ip))
+	}
+	for _, mx := range r.DNS.MXRecords {
+		g.link(root, EdgeMX, g.host(mx.Host))
+	}
+	for _, ip := range sortedKeys(r.DNS.ReverseDNS) {
+		ipNode := g.node(NodeIP, ip, ip)
+		for _, name := range r.DNS.ReverseDNS[ip] {
+			g.link(ipNode, EdgePTR, g.host(name))
+		}
+	}
+	for _, loc := range r.Geo {
+		n := g.node(NodeIP, loc.IP, loc.IP)
+		setProp(n, "country", loc.Country)
+		setProp(n, "city", loc.City)
+		if loc.Latitude != 0 || loc.Longitude != 0 {
+			setProp(n, "latitude", strconv.FormatFloat(loc.Latitude, 'f', 4, 64))
+			setProp(n, "longitude", strconv.FormatFloat(loc.Longitude, 'f', 4, 64))
+		}
+	}
+
+	// CT log entries give the names; downloaded certificates add the details
+	for _, entry := range r.CTLogs {
+		cert := g.node(NodeCertificate, strconv.Itoa(entry.MinCertID), "crt.sh #"+strconv.Itoa(entry.MinCertID))
+		setProp(cert, "not_before", entry.NotBefore)
+		setProp(cert, "not_after", entry.NotAfter)
+		if entry.IssuerName != "" {
+			g.link(cert, EdgeIssuedBy, g.node(NodeIssuer, entry.IssuerName, entry.IssuerName))
+		}
+		for _, name := range strings.Split(entry.NameValue, "\n") {
+			if strings.TrimSpace(name) != "" {
+				g.link(cert, EdgeCovers, g.host(name))
+			}
+		}
+	}
+	for _, c := range r.Certificates {
+		cert := g.node(NodeCertificate, strconv.Itoa(c.ID), "crt.sh #"+strconv.Itoa(c.ID))
+		setProp(cert, "subject", c.Subject)
+		setProp(cert, "serial_number", c.SerialNumber)
+		setTime(cert, "not_before", c.NotBefore)
+		setTime(cert, "not_after", c.NotAfter)
+		setProp(cert, "sha256", c.FingerprintSHA256)
+		if c.Issuer != "" {
+			g.link(cert, EdgeIssuedBy, g.node(NodeIssuer, c.Issuer, c.Issuer))
+		}
+		for _, name := range c.DNSNames {
+			g.link(cert, EdgeCovers, g.host(name))
+		}
+	}
+
+	if r.WHOIS.Registrar != "" {
+		g.link(apex, EdgeRegisteredAt, g.node(NodeRegistrar, r.WHOIS.Registrar, r.WHOIS.Registrar))
+	}
+	for _, ns := range r.WHOIS.NameServers {
+		g.link(apex, EdgeNameServer, g.host(ns))
+	}
+
+	if content := r.Web.Content; content != nil {
+		page := g.node(NodePage, r.Web.URL, r.Web.URL)
+		setProp(page, "title", content.Title)
+		g.link(root, EdgeHosts, page)
+		for _, platform := range sortedKeys(content.SocialMedia) {
+			for _, link := range content.SocialMedia[platform] {
+				social := g.node(NodeSocial, link, link)
+				setProp(social, "platform", platform)
+				g.link(page, EdgeLinksTo, social)
+			}
+		}
+		for _, email := range content.Emails {
+			email = strings.ToLower(email)
+			g.link(page, EdgeMentions, g.node(NodeEmail, email, email))
+		}
+		for _, phone := range content.PhoneNumbers {
+			g.link(page, EdgeMentions, g.node(NodePhone, phone, phone))
+		}
+	}
+
+	for _, f := range r.Findings {
+		finding := g.node(NodeFinding, r.Domain+"/"+f.Rule+"/"+f.Subject, f.Title)
+		setProp(finding, "rule", f.Rule)
+		setProp(finding, "severity", f.Severity)
+		setProp(finding, "subject", f.Subject)
+		setProp(finding, "evidence", f.Evidence)
+		setProp(finding, "remediation", f.Remediation)
+		g.link(root, EdgeHasFinding, finding)
+	}
+
+	for _, p := range r.Pivots {
+		if p.Report != nil {
+			g.addReport(p.Report)
+		}
+	}
+}
+
+func setProp(n *Node, key, value string) {
+	if value != "" {
+		n.Props[key] = value
+	}
+}
+
+func setTime(n *Node, key string, t time.Time) {
+	if !t.IsZero() {
+		n.Props[key] = t.Format(time.RFC3339)
+	}
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
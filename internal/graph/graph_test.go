@@ -0,0 +1,39 @@
+package graph
+
+import (
+	"testing"
+
+	"github.com/qepting91/gomain_analysis/internal/model"
+)
+
+func TestBuildIPTarget(t *testing.T) {
+	pivot := model.NewDomainReport("198.51.100.7")
+	pivot.Target = model.Target{Host: "198.51.100.7", IsIP: true}
+	pivot.DNS.ReverseDNS = map[string][]string{"198.51.100.7": {"mail.example.com"}}
+
+	r := model.NewDomainReport("203.0.113.10")
+	r.Target = model.Target{Host: "203.0.113.10", IsIP: true}
+	r.DNS.ReverseDNS = map[string][]string{"203.0.113.10": {"www.example.com"}}
+	r.Pivots = []model.Pivot{{Target: "198.51.100.7", Report: pivot}}
+
+	g := Build(r)
+
+	linked := make(map[string]bool)
+	for _, e := range g.Edges {
+		linked[e.Source] = true
+		linked[e.Target] = true
+	}
+	for _, n := range g.Nodes {
+		if n.Type == NodeDomain && !linked[n.ID] {
+			t.Errorf("orphan Domain node %s", n.ID)
+		}
+	}
+	for _, id := range []string{"ip:203.0.113.10", "ip:198.51.100.7"} {
+		n, ok := g.byID[id]
+		if !ok {
+			t.Errorf("missing root node %s", id)
+		} else if n.Type != NodeIP {
+			t.Errorf("root node %s has type %s, want %s", id, n.Type, NodeIP)
+		}
+	}
+}
@@ -0,0 +1,240 @@
+package misp
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/qepting91/gomain_analysis/internal/model"
+	"github.com/qepting91/gomain_analysis/internal/transport"
+)
+
+// Attribute categories used by the export
+const (
+	CategoryNetwork     = "Network activity"
+	CategoryAttribution = "Attribution"
+	CategoryOther       = "Other"
+)
+
+// Event levels as MISP expects them: threat level 4 is "undefined", analysis
+// 1 is "ongoing" and 2 "completed", distribution 0 is "your organisation only"
+// and 5 on an attribute means "inherit from the event"
+const (
+	threatUndefined     = "4"
+	analysisOngoing     = "1"
+	analysisCompleted   = "2"
+	distributionOrgOnly = "0"
+	distributionInherit = "5"
+)
+
+// Event is a MISP event as accepted by /events/add
+type Event struct {
+	UUID          string      `json:"uuid"`
+	Info          string      `json:"info"`
+	Date          string      `json:"date"`
+	ThreatLevelID string      `json:"threat_level_id"`
+	Analysis      string      `json:"analysis"`
+	Distribution  string      `json:"distribution"`
+	Published     bool        `json:"published"`
+	Timestamp     string      `json:"timestamp"`
+	Attributes    []Attribute `json:"Attribute"`
+}
+
+// Attribute is a single indicator of an event
+type Attribute struct {
+	UUID         string `json:"uuid"`
+	Type         string `json:"type"`
+	Category     string `json:"category"`
+	Value        string `json:"value"`
+	ToIDs        bool   `json:"to_ids"`
+	Distribution string `json:"distribution"`
+	Comment      string `json:"comment,omitempty"`
+}
+
+// wrapper is the envelope MISP uses for events in both directions
+type wrapper struct {
+	Event *Event `json:"Event"`
+}
+
+// builder adds attributes once per type and value
+type builder struct {
+	ev   *Event
+	seen map[string]bool
+}
+
+func (b *builder) add(typ, category, value string, toIDs bool, comment string) {
+	value = strings.TrimSpace(value)
+	key := typ + "|" + value
+	if value == "" || b.seen[key] {
+		return
+	}
+	b.seen[key] = true
+	b.ev.Attributes = append(b.ev.Attributes, Attribute{
+		UUID:         uuid5(b.ev.UUID + "|" + key),
+		Type:         typ,
+		Category:     category,
+		Value:        value,
+		ToIDs:        toIDs,
+		Distribution: distributionInherit,
+		Comment:      comment,
+	})
+}
+
+// Build maps a report to a MISP event. Network indicators (names,
+// addresses, certificate fingerprints) are flagged for IDS export; contact
+// details, the registrar and findings are context only. The event and
+// attribute UUIDs are derived from the domain and scan time, so pushing the
+// same scan twice is rejected by MISP instead of creating a duplicate.
+func Build(r *model.DomainReport) *Event {
+	analysis := analysisCompleted
+	if r.Interrupted {
+		analysis = analysisOngoing
+	}
+	ev := &Event{
+		UUID:          uuid5(r.Domain + "|" + r.GeneratedAt.UTC().Format(time.RFC3339Nano)),
+		Info:          "OSINT scan of " + r.Domain,
+		Date:          r.GeneratedAt.UTC().Format("2006-01-02"),
+		ThreatLevelID: threatUndefined,
+		Analysis:      analysis,
+		Distribution:  distributionOrgOnly,
+		Timestamp:     fmt.Sprint(r.GeneratedAt.Unix()),
+	}
+	b := &builder{ev: ev, seen: make(map[string]bool)}
+
+	switch {
+	case r.Target.IsIP:
+		b.add("ip-dst", CategoryNetwork, r.Domain, true, "Scan target")
+	case r.Target.Apex != "" && r.Target.Apex != r.Domain:
+		b.add("hostname", CategoryNetwork, r.Domain, true, "Scan target")
+		b.add("domain", CategoryNetwork, r.Target.Apex, true, "Registrable domain of the scan target")
+	default:
+		b.add("domain", CategoryNetwork, r.Domain, true, "Scan target")
+	}
+
+	for _, ip := range r.DNS.ARecords {
+		b.add("ip-dst", CategoryNetwork, ip, true, "Address of "+r.Domain)
+	}
+	for _, name := range r.Subdomains() {
+		b.add("hostname", CategoryNetwork, name, true, "Seen in certificate transparency logs")
+	}
+	for _, cert := range r.Certificates {
+		comment := fmt.Sprintf("crt.sh certificate %d, subject %s", cert.ID, cert.Subject)
+		b.add("x509-fingerprint-sha256", CategoryNetwork, cert.FingerprintSHA256, true, comment)
+		b.add("x509-fingerprint-sha1", CategoryNetwork, cert.FingerprintSHA1, true, comment)
+	}
+	b.add("whois-registrar", CategoryAttribution, r.WHOIS.Registrar, false, "")
+	if content := r.Web.Content; content != nil {
+		for _, email := range content.Emails {
+			b.add("email", CategoryAttribution, strings.ToLower(email), false, "Found on "+r.Web.URL)
+		}
+		for _, phone := range content.PhoneNumbers {
+			b.add("phone-number", CategoryOther, phone, false, "Found on "+r.Web.URL)
+		}
+	}
+	for _, f := range r.Findings {
+		value := fmt.Sprintf("[%s] %s: %s - %s", strings.ToUpper(f.Severity), f.Title, f.Subject, f.Evidence)
+		b.add("text", CategoryOther, value, false, f.Remediation)
+	}
+	return ev
+}
+
+// Write renders the report as a MISP event JSON document
+func Write(w io.Writer, r *model.DomainReport) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(wrapper{Event: Build(r)}); err != nil {
+		return fmt.Errorf("failed to encode MISP event: %v", err)
+	}
+	return nil
+}
+
+// Client pushes events to a MISP instance
+type Client struct {
+	// URL is the base URL of the instance, e.g. https://misp.example.org
+	URL string
+	// Key is the automation key of the MISP user
+	Key    string
+	Client *http.Client
+}
+
+// Push creates the event and returns the ID MISP assigned to it
+func (c *Client) Push(ctx context.Context, ev *Event) (string, error) {
+	body, err := json.Marshal(wrapper{Event: ev})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode MISP event: %v", err)
+	}
+	url := strings.TrimSuffix(c.URL, "/") + "/events/add"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("failed to create MISP request: %v", err)
+	}
+	req.Header.Set("Authorization", c.Key)
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Content-Type", "application/json")
+
+	client := c.Client
+	if client == nil {
+		client = &http.Client{Timeout: time.Minute, Transport: transport.RoundTripper()}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to push event to MISP: %v", err)
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", fmt.Errorf("failed to read MISP response: %v", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		// MISP puts the reason in message and, for validation failures such
+		// as a duplicate UUID, the details in errors
+		var failure struct {
+			Message string          `json:"message"`
+			Errors  json.RawMessage `json:"errors"`
+		}
+		if json.Unmarshal(respBody, &failure) == nil && failure.Message != "" {
+			var details bytes.Buffer
+			if len(failure.Errors) > 0 && json.Compact(&details, failure.Errors) == nil && details.String() != "null" {
+				return "", fmt.Errorf("MISP returned status %d: %s: %s", resp.StatusCode, failure.Message, details.String())
+			}
+			return "", fmt.Errorf("MISP returned status %d: %s", resp.StatusCode, failure.Message)
+		}
+		return "", fmt.Errorf("MISP returned status code: %d", resp.StatusCode)
+	}
+
+	var created struct {
+		Event struct {
+			ID string `json:"id"`
+		} `json:"Event"`
+	}
+	if err := json.Unmarshal(respBody, &created); err != nil {
+		return "", fmt.Errorf("failed to parse MISP response: %v", err)
+	}
+	if created.Event.ID == "" {
+		return "", fmt.Errorf("MISP response has no event ID")
+	}
+	return created.Event.ID, nil
+}
+
+// namespace is the RFC 4122 URL namespace; names get a prefix of their own
+var namespace = [16]byte{0x6b, 0xa7, 0xb8, 0x11, 0x9d, 0xad, 0x11, 0xd1, 0x80, 0xb4, 0x00, 0xc0, 0x4f, 0xd4, 0x30, 0xc8}
+
+// uuid5 returns the name-based (version 5) UUID of name
+func uuid5(name string) string {
+	h := sha1.New()
+	h.Write(namespace[:])
+	h.Write([]byte("gomain_analysis/misp/" + name))
+	u := h.Sum(nil)[:16]
+	u[6] = (u[6] & 0x0f) | 0x50
+	u[8] = (u[8] & 0x3f) | 0x80
+	s := hex.EncodeToString(u)
+	return s[0:8] + "-" + s[8:12] + "-" + s[12:16] + "-" + s[16:20] + "-" + s[20:]
+}
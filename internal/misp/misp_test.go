@@ -0,0 +1,212 @@
+package misp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/qepting91/gomain_analysis/internal/model"
+	"github.com/qepting91/gomain_analysis/internal/parser"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+func testReport() *model.DomainReport {
+	r := model.NewDomainReport("www.example.com")
+	r.Target = model.Target{Input: "www.example.com", Host: "www.example.com", Apex: "example.com"}
+	r.GeneratedAt = time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	r.DNS.ARecords = []string{"203.0.113.10", "203.0.113.10"}
+	r.CTLogs = []model.CTLog{{MinCertID: 1, NameValue: "www.example.com\nmail.example.com\n*.example.com\nother.test"}}
+	r.Certificates = []model.Certificate{{
+		ID:                42,
+		Subject:           "CN=www.example.com",
+		FingerprintSHA1:   "da39a3ee5e6b4b0d3255bfef95601890afd80709",
+		FingerprintSHA256: "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+		DNSNames:          []string{"www.example.com"},
+	}}
+	r.WHOIS.Registrar = "Example Registrar, Inc."
+	r.Web = model.WebInfo{
+		URL:     "https://www.example.com/",
+		Content: &parser.ParsedContent{Emails: []string{"Info@Example.com", "info@example.com"}, PhoneNumbers: []string{"+1-555-0100"}},
+	}
+	r.Findings = []model.Finding{{
+		Rule:        "dmarc-missing",
+		Title:       "No DMARC policy",
+		Severity:    model.SeverityMedium,
+		Subject:     "example.com",
+		Evidence:    "no v=DMARC1 TXT record at _dmarc.example.com",
+		Remediation: "Publish a DMARC record.",
+	}}
+	return r
+}
+
+func TestWriteGolden(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, testReport()); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	golden := filepath.Join("testdata", "event.json")
+	if *update {
+		if err := os.WriteFile(golden, buf.Bytes(), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("reading golden file (run with -update to create it): %v", err)
+	}
+	if !bytes.Equal(buf.Bytes(), want) {
+		t.Errorf("event differs from %s:\n%s", golden, buf.String())
+	}
+}
+
+func TestBuildTargets(t *testing.T) {
+	tests := []struct {
+		name   string
+		target model.Target
+		want   string
+	}{
+		{"apex", model.Target{Host: "example.com", Apex: "example.com"}, "domain example.com"},
+		{"subdomain", model.Target{Host: "www.example.com", Apex: "example.com"}, "hostname www.example.com, domain example.com"},
+		{"address", model.Target{Host: "203.0.113.10", IsIP: true}, "ip-dst 203.0.113.10"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := model.NewDomainReport(tt.target.Host)
+			r.Target = tt.target
+			var got []string
+			for _, a := range Build(r).Attributes {
+				got = append(got, a.Type+" "+a.Value)
+			}
+			if strings.Join(got, ", ") != tt.want {
+				t.Errorf("attributes = %v, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildIsDeterministic(t *testing.T) {
+	a, b := Build(testReport()), Build(testReport())
+	if a.UUID != b.UUID || len(a.Attributes) != len(b.Attributes) {
+		t.Fatalf("two builds of the same scan differ: %s, %s", a.UUID, b.UUID)
+	}
+	later := testReport()
+	later.GeneratedAt = later.GeneratedAt.Add(time.Hour)
+	if Build(later).UUID == a.UUID {
+		t.Error("a later scan got the same event UUID")
+	}
+}
+
+// stub is a MISP instance that records what it was sent
+type stub struct {
+	status int
+	body   string
+
+	path, auth, contentType string
+	event                   Event
+}
+
+func (s *stub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.path = r.Method + " " + r.URL.Path
+	s.auth = r.Header.Get("Authorization")
+	s.contentType = r.Header.Get("Content-Type")
+	data, _ := io.ReadAll(r.Body)
+	var envelope wrapper
+	if json.Unmarshal(data, &envelope) == nil && envelope.Event != nil {
+		s.event = *envelope.Event
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(s.status)
+	io.WriteString(w, s.body)
+}
+
+func TestPush(t *testing.T) {
+	s := &stub{status: http.StatusOK, body: `{"Event": {"id": "1234", "uuid": "ignored", "info": "OSINT scan of www.example.com"}}`}
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+
+	ev := Build(testReport())
+	c := &Client{URL: ts.URL + "/", Key: "secret-key", Client: ts.Client()}
+	id, err := c.Push(context.Background(), ev)
+	if err != nil {
+		t.Fatalf("Push: %v", err)
+	}
+	if id != "1234" {
+		t.Errorf("event ID = %q, want 1234", id)
+	}
+	if s.path != "POST /events/add" {
+		t.Errorf("request = %s, want POST /events/add", s.path)
+	}
+	if s.auth != "secret-key" {
+		t.Errorf("Authorization = %q", s.auth)
+	}
+	if s.contentType != "application/json" {
+		t.Errorf("Content-Type = %q", s.contentType)
+	}
+	if s.event.UUID != ev.UUID || len(s.event.Attributes) != len(ev.Attributes) {
+		t.Errorf("sent event %s with %d attributes, want %s with %d", s.event.UUID, len(s.event.Attributes), ev.UUID, len(ev.Attributes))
+	}
+}
+
+func TestPushErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		want   string
+	}{
+		{
+			"duplicate event",
+			http.StatusForbidden,
+			`{"name": "Could not add Event", "message": "Could not add Event", "url": "/events/add", "errors": {"Event": {"uuid": ["An event with this uuid already exists."]}}}`,
+			`MISP returned status 403: Could not add Event: {"Event":{"uuid":["An event with this uuid already exists."]}}`,
+		},
+		{
+			"bad key",
+			http.StatusForbidden,
+			`{"name": "Authentication failed.", "message": "Authentication failed.", "url": "/events/add"}`,
+			"MISP returned status 403: Authentication failed.",
+		},
+		{
+			"not JSON",
+			http.StatusBadGateway,
+			"<html>Bad Gateway</html>",
+			"MISP returned status code: 502",
+		},
+		{
+			"success without an ID",
+			http.StatusOK,
+			`{"saved": true}`,
+			"MISP response has no event ID",
+		},
+		{
+			"success that is not JSON",
+			http.StatusOK,
+			"OK",
+			"failed to parse MISP response",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := httptest.NewServer(&stub{status: tt.status, body: tt.body})
+			defer ts.Close()
+			c := &Client{URL: ts.URL, Key: "key", Client: ts.Client()}
+			id, err := c.Push(context.Background(), Build(testReport()))
+			if err == nil {
+				t.Fatalf("Push returned ID %q and no error", id)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %q, want %q", err, tt.want)
+			}
+		})
+	}
+}
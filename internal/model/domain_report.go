@@ -0,0 +1,300 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/qepting91/gomain_analysis/internal/parser"
+)
+
+// DomainReport holds everything collected about a single domain.
+// Every analysis module fills in its own section; renderers and exporters
+// read from it instead of from pre-formatted text.
+type DomainReport struct {
+	Domain       string            `json:"domain"`
+	Target       Target            `json:"target"`
+	GeneratedAt  time.Time         `json:"generated_at"`
+	Interrupted  bool              `json:"interrupted"`
+	Profile      string            `json:"profile"`
+	Skipped      []string          `json:"skipped_modules"`
+	Modules      []ModuleStatus    `json:"modules"`
+	CTLogs       []CTLog           `json:"ct_logs"`
+	Certificates []Certificate     `json:"certificates"`
+	DNS          DNSInfo           `json:"dns"`
+	WHOIS        WHOISInfo         `json:"whois"`
+	Web          WebInfo           `json:"web"`
+	Files        []CommonFile      `json:"files,omitempty"`
+	Wayback      []WaybackSnapshot `json:"wayback"`
+	Dorks        []DorkResult      `json:"dorks"`
+	Geo          []GeoLocation     `json:"geolocation"`
+	Pivots       []Pivot           `json:"pivots,omitempty"`
+	NotProbed    []NotProbed       `json:"not_probed,omitempty"`
+	Findings     []Finding         `json:"findings,omitempty"`
+}
+
+// Target is the normalized form of what the user asked to scan
+type Target struct {
+	// Input is the target exactly as given
+	Input string `json:"input"`
+	// Host is the lower-case, punycode host name or IP address
+	Host string `json:"host"`
+	// Apex is the registrable domain of Host per the Public Suffix List;
+	// empty for IP addresses
+	Apex string `json:"apex,omitempty"`
+	// URL is where the website is fetched from
+	URL  string `json:"url"`
+	IsIP bool   `json:"is_ip,omitempty"`
+}
+
+// NewDomainReport returns an empty report for the given domain
+func NewDomainReport(domain string) *DomainReport {
+	return &DomainReport{
+		Domain:      domain,
+		Target:      Target{Input: domain, Host: domain},
+		GeneratedAt: time.Now().UTC(),
+		DNS: DNSInfo{
+			ReverseDNS: make(map[string][]string),
+		},
+	}
+}
+
+// Pivot is a host or IP discovered in a report and analyzed in turn
+type Pivot struct {
+	Target string `json:"target"`
+	// Depth is 1 for leads found in the main report, 2 for leads found in
+	// those pivots, and so on
+	Depth int `json:"depth"`
+	// From is the target whose report contained the lead
+	From string `json:"from"`
+	// Reason says what kind of finding the lead was, e.g. "certificate SAN"
+	Reason string `json:"reason"`
+	// Evidence identifies the finding itself, e.g. "crt.sh #123"
+	Evidence string        `json:"evidence,omitempty"`
+	Report   *DomainReport `json:"report,omitempty"`
+	Error    string        `json:"error,omitempty"`
+}
+
+// NotProbed is a host the scan discovered but left alone because the scope
+// file does not allow probing it
+type NotProbed struct {
+	Target string `json:"target"`
+	// Module is what would have probed it, or "pivot"
+	Module string `json:"module"`
+	// Found says where the target came from, e.g. "A record of example.com"
+	Found  string `json:"found,omitempty"`
+	Reason string `json:"reason"`
+}
+
+// ModuleCompleted reports whether the named module ran to completion
+func (r *DomainReport) ModuleCompleted(name string) bool {
+	for _, m := range r.Modules {
+		if m.Name == name {
+			return m.Status == StatusCompleted
+		}
+	}
+	return false
+}
+
+// Subdomains returns every host name under the registrable domain seen in
+// certificate transparency entries and certificate SANs, excluding wildcards
+func (r *DomainReport) Subdomains() []string {
+	base := r.Domain
+	if r.Target.Apex != "" {
+		base = r.Target.Apex
+	}
+	seen := make(map[string]bool)
+	var names []string
+	add := func(name string) {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" || strings.HasPrefix(name, "*") || seen[name] {
+			return
+		}
+		if name != base && !strings.HasSuffix(name, "."+base) {
+			return
+		}
+		seen[name] = true
+		names = append(names, name)
+	}
+	for _, entry := range r.CTLogs {
+		for _, name := range strings.Split(entry.NameValue, "\n") {
+			add(name)
+		}
+	}
+	for _, cert := range r.Certificates {
+		for _, name := range cert.DNSNames {
+			add(name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Module run states recorded in ModuleStatus
+const (
+	StatusCompleted = "completed"
+	StatusFailed    = "failed"
+	StatusTimeout   = "timeout"
+	StatusCancelled = "cancelled"
+)
+
+// ModuleStatus records how a single analysis module finished
+type ModuleStatus struct {
+	Name     string        `json:"name"`
+	Status   string        `json:"status"`
+	Error    string        `json:"error,omitempty"`
+	Started  time.Time     `json:"started"`
+	Duration time.Duration `json:"duration_ns"`
+	// Evidence lists the IDs of the raw artifacts the module saved
+	Evidence []string `json:"evidence,omitempty"`
+}
+
+// CTLog represents a single record from crt.sh
+type CTLog struct {
+	IssuerCaID        int    `json:"issuer_ca_id"`
+	IssuerName        string `json:"issuer_name"`
+	NameValue         string `json:"name_value"`
+	MinCertID         int    `json:"min_cert_id"`
+	MinEntryTimestamp string `json:"min_entry_timestamp"`
+	NotBefore         string `json:"not_before"`
+	NotAfter          string `json:"not_after"`
+}
+
+// Certificate represents a certificate found in certificate transparency logs
+type Certificate struct {
+	ID                int       `json:"id"`
+	LoggedAt          string    `json:"logged_at,omitempty"`
+	NameValue         string    `json:"name_value,omitempty"`
+	Subject           string    `json:"subject"`
+	Issuer            string    `json:"issuer"`
+	SerialNumber      string    `json:"serial_number"`
+	NotBefore         time.Time `json:"not_before"`
+	NotAfter          time.Time `json:"not_after"`
+	DNSNames          []string  `json:"dns_names"`
+	FingerprintSHA1   string    `json:"fingerprint_sha1"`
+	FingerprintSHA256 string    `json:"fingerprint_sha256"`
+	// Evidence is the ID of the saved PEM file
+	Evidence []string `json:"evidence,omitempty"`
+}
+
+// DNSInfo holds forward and reverse DNS results
+type DNSInfo struct {
+	ARecords   []string            `json:"a_records"`
+	MXRecords  []MXRecord          `json:"mx_records"`
+	ReverseDNS map[string][]string `json:"reverse_dns"`
+	// DMARC is nil when the policy was not looked up
+	DMARC *DMARCInfo `json:"dmarc,omitempty"`
+}
+
+// DMARCInfo is the DMARC policy that applies to the host
+type DMARCInfo struct {
+	// Domain is where the policy was found, or the last name checked
+	Domain string `json:"domain"`
+	// Record is the policy itself, empty when none is published
+	Record string `json:"record,omitempty"`
+	// Evidence holds the saved TXT answers for every name checked
+	Evidence []string `json:"evidence,omitempty"`
+}
+
+// MXRecord is a mail exchanger for the domain
+type MXRecord struct {
+	Host       string `json:"host"`
+	Preference uint16 `json:"preference"`
+}
+
+// String formats the record as "<preference> <host>"
+func (mx MXRecord) String() string {
+	return fmt.Sprintf("%d %s", mx.Preference, mx.Host)
+}
+
+// WHOISInfo holds the WHOIS response for the domain and the fields parsed from it
+type WHOISInfo struct {
+	Registrar   string     `json:"registrar,omitempty"`
+	CreatedAt   *time.Time `json:"created_at,omitempty"`
+	UpdatedAt   *time.Time `json:"updated_at,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	NameServers []string   `json:"name_servers,omitempty"`
+	Status      []string   `json:"status,omitempty"`
+	Raw         string     `json:"raw"`
+	Evidence    []string   `json:"evidence,omitempty"`
+}
+
+// WebInfo holds the fetched and parsed website content
+type WebInfo struct {
+	URL     string                `json:"url"`
+	Content *parser.ParsedContent `json:"content,omitempty"`
+	// Evidence covers the fetched page and any redirects on the way
+	Evidence []string `json:"evidence,omitempty"`
+}
+
+// CommonFile is a well-known file the web server returned, such as
+// security.txt or an exposed .git/config
+type CommonFile struct {
+	Path string `json:"path"`
+	URL  string `json:"url"`
+	Size int    `json:"size"`
+	// Content is truncated to MaxFileContent bytes
+	Content string `json:"content"`
+	// Evidence is the saved response, and any redirects on the way
+	Evidence []string `json:"evidence,omitempty"`
+}
+
+// MaxFileContent caps how much of a common file is kept in the report
+const MaxFileContent = 64 << 10
+
+// Severities of findings, from least to most serious
+const (
+	SeverityInfo     = "info"
+	SeverityLow      = "low"
+	SeverityMedium   = "medium"
+	SeverityHigh     = "high"
+	SeverityCritical = "critical"
+)
+
+// Finding is a problem a rule detected in the collected data
+type Finding struct {
+	Rule     string `json:"rule"`
+	Title    string `json:"title"`
+	Severity string `json:"severity"`
+	// Subject is what the finding is about: a host, certificate or URL
+	Subject string `json:"subject"`
+	// Evidence says what in the data triggered the rule
+	Evidence string `json:"evidence"`
+	// Artifacts are the IDs of the raw evidence files, when collected
+	Artifacts   []string `json:"artifacts,omitempty"`
+	Remediation string   `json:"remediation"`
+}
+
+// WaybackSnapshot represents an archived copy of the site
+type WaybackSnapshot struct {
+	URL       string    `json:"url"`
+	Timestamp time.Time `json:"timestamp"`
+	Status    string    `json:"status"`
+}
+
+// DorkResult represents a single Google dork query
+type DorkResult struct {
+	Query string `json:"query"`
+	URL   string `json:"url,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// GeoLocation holds the GeoLite2 lookup result for an IP address
+type GeoLocation struct {
+	IP             string  `json:"ip"`
+	City           string  `json:"city,omitempty"`
+	Region         string  `json:"region,omitempty"`
+	Country        string  `json:"country,omitempty"`
+	CountryCode    string  `json:"country_code,omitempty"`
+	Continent      string  `json:"continent,omitempty"`
+	ContinentCode  string  `json:"continent_code,omitempty"`
+	Latitude       float64 `json:"latitude"`
+	Longitude      float64 `json:"longitude"`
+	TimeZone       string  `json:"time_zone,omitempty"`
+	AccuracyRadius uint16  `json:"accuracy_radius"`
+	PostalCode     string  `json:"postal_code,omitempty"`
+	AnonymousProxy bool    `json:"anonymous_proxy"`
+	Satellite      bool    `json:"satellite_provider"`
+	Anycast        bool    `json:"anycast"`
+}
@@ -0,0 +1,66 @@
+package modules
+
+import (
+	"context"
+	"time"
+
+	"github.com/qepting91/gomain_analysis/internal/crt"
+	"github.com/qepting91/gomain_analysis/internal/evidence"
+	"github.com/qepting91/gomain_analysis/internal/model"
+	"github.com/qepting91/gomain_analysis/internal/settings"
+)
+
+func init() {
+	Register("crt", func(*settings.Config) Module {
+		return &crtModule{base{
+			name:        "crt",
+			description: "Fetching SSL/TLS certificates",
+			passive:     true,
+			scope:       ScopeApex,
+			timeout:     3 * time.Minute,
+		}}
+	})
+}
+
+// CertificatesResult holds the CT log entries and the certificates parsed from them
+type CertificatesResult struct {
+	CTLogs       []model.CTLog       `json:"ct_logs"`
+	Certificates []model.Certificate `json:"certificates"`
+}
+
+// Apply implements Result
+func (c *CertificatesResult) Apply(r *model.DomainReport) {
+	r.CTLogs = c.CTLogs
+	r.Certificates = c.Certificates
+}
+
+type crtModule struct{ base }
+
+// Run queries crt.sh and downloads every logged certificate, keeping
+// whatever was collected if the deadline is reached
+func (m *crtModule) Run(ctx context.Context, t Target) (Result, error) {
+	logs, err := crt.QueryByDomain(ctx, t.Name(m.Scope()))
+	if err != nil {
+		return nil, err
+	}
+	res := &CertificatesResult{CTLogs: logs}
+
+	for _, entry := range logs {
+		if err := ctx.Err(); err != nil {
+			return res, err
+		}
+		pemCtx, tracker := evidence.Track(ctx)
+		pemData, err := crt.DownloadPemFile(pemCtx, entry.MinCertID)
+		if err != nil {
+			continue
+		}
+		cert, err := crt.ParseCertificate(pemData)
+		if err != nil {
+			continue
+		}
+		c := crt.NewCertificate(entry, cert)
+		c.Evidence = tracker.IDs()
+		res.Certificates = append(res.Certificates, c)
+	}
+	return res, nil
+}
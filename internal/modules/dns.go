@@ -0,0 +1,160 @@
+package modules
+
+import (
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/qepting91/gomain_analysis/internal/dns"
+	"github.com/qepting91/gomain_analysis/internal/evidence"
+	"github.com/qepting91/gomain_analysis/internal/model"
+	"github.com/qepting91/gomain_analysis/internal/scope"
+	"github.com/qepting91/gomain_analysis/internal/settings"
+)
+
+func init() {
+	Register("dns", func(cfg *settings.Config) Module {
+		return &dnsModule{
+			base: base{
+				name:        "dns",
+				description: "Resolving A, MX and DMARC records",
+				passive:     true,
+				timeout:     30 * time.Second,
+			},
+			resolver: newResolver(cfg),
+		}
+	})
+	Register("reverse", func(cfg *settings.Config) Module {
+		return &reverseModule{
+			base: base{
+				name:        "reverse",
+				description: "Performing reverse DNS lookup",
+				deps:        []string{"dns"},
+				passive:     true,
+				timeout:     time.Minute,
+			},
+			resolver: newResolver(cfg),
+		}
+	})
+}
+
+// newResolver applies the configured DNS tunables to a resolver
+func newResolver(cfg *settings.Config) *dns.DNSResolver {
+	resolver := dns.NewDNSResolver()
+	resolver.Threads = cfg.DNS.Threads
+	resolver.Resolver = cfg.DNS.Resolver
+	resolver.UseDefaults = cfg.DNS.UseDefaults
+	return resolver
+}
+
+// DNSResult holds the forward records of the domain
+type DNSResult struct {
+	ARecords  []string         `json:"a_records"`
+	MXRecords []model.MXRecord `json:"mx_records"`
+	DMARC     *model.DMARCInfo `json:"dmarc,omitempty"`
+}
+
+// Apply implements Result
+func (d *DNSResult) Apply(r *model.DomainReport) {
+	r.DNS.ARecords = d.ARecords
+	r.DNS.MXRecords = d.MXRecords
+	r.DNS.DMARC = d.DMARC
+}
+
+type dnsModule struct {
+	base
+	resolver *dns.DNSResolver
+}
+
+func (m *dnsModule) Run(ctx context.Context, t Target) (Result, error) {
+	records, err := m.resolver.ResolveARecords(ctx, t.Name(m.Scope()))
+	if err != nil {
+		return nil, err
+	}
+	res := &DNSResult{ARecords: records}
+
+	// Many domains legitimately have no MX, so this is not fatal
+	mxRecords, err := m.resolver.ResolveMXRecords(ctx, t.Name(m.Scope()))
+	if err != nil {
+		log.Printf("Error resolving MX records: %v", err)
+	}
+	for _, mx := range mxRecords {
+		res.MXRecords = append(res.MXRecords, model.MXRecord{
+			Host:       strings.TrimSuffix(mx.Host, "."),
+			Preference: mx.Pref,
+		})
+	}
+
+	if !t.IsIP {
+		dmarc, err := m.lookupDMARC(ctx, t)
+		if err != nil {
+			log.Printf("Error looking up DMARC policy: %v", err)
+		}
+		res.DMARC = dmarc
+	}
+	return res, ctx.Err()
+}
+
+// lookupDMARC finds the policy the way receivers do: at _dmarc of the host,
+// then of its registrable domain. It returns nil if a lookup failed, since
+// the absence of a policy is then unknown.
+func (m *dnsModule) lookupDMARC(ctx context.Context, t Target) (*model.DMARCInfo, error) {
+	names := []string{t.Host}
+	if t.Apex != "" && t.Apex != t.Host {
+		names = append(names, t.Apex)
+	}
+	lookupCtx, tracker := evidence.Track(ctx)
+	var info *model.DMARCInfo
+	for _, name := range names {
+		records, err := m.resolver.ResolveTXTRecords(lookupCtx, "_dmarc."+name)
+		if err != nil {
+			return nil, err
+		}
+		info = &model.DMARCInfo{Domain: name}
+		for _, record := range records {
+			if strings.HasPrefix(strings.ToLower(strings.TrimSpace(record)), "v=dmarc1") {
+				info.Record = record
+				break
+			}
+		}
+		if info.Record != "" {
+			break
+		}
+	}
+	info.Evidence = tracker.IDs()
+	return info, nil
+}
+
+// ReverseDNSResult maps each IP to the host names pointing back at it
+type ReverseDNSResult struct {
+	Names map[string][]string
+	// NotProbed lists the addresses left out because of the scope
+	NotProbed []model.NotProbed
+}
+
+// Apply implements Result
+func (d *ReverseDNSResult) Apply(r *model.DomainReport) {
+	r.DNS.ReverseDNS = d.Names
+	r.NotProbed = append(r.NotProbed, d.NotProbed...)
+}
+
+type reverseModule struct {
+	base
+	resolver *dns.DNSResolver
+}
+
+func (m *reverseModule) Run(ctx context.Context, t Target) (Result, error) {
+	res := &ReverseDNSResult{}
+	for _, ip := range t.Report.DNS.ARecords {
+		if err := scope.Check(ip); err != nil {
+			res.NotProbed = append(res.NotProbed, notProbed(m.name, ip, "A record of "+t.Host, err))
+		}
+	}
+	reverseDNS, err := m.resolver.ReverseLookup(ctx, t.Report.DNS.ARecords)
+	if reverseDNS == nil {
+		return nil, err
+	}
+	res.Names = reverseDNS
+	return res, err
+}
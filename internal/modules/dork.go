@@ -0,0 +1,48 @@
+package modules
+
+import (
+	"context"
+	"time"
+
+	"github.com/qepting91/gomain_analysis/internal/dork"
+	"github.com/qepting91/gomain_analysis/internal/model"
+	"github.com/qepting91/gomain_analysis/internal/settings"
+)
+
+func init() {
+	Register("dork", func(cfg *settings.Config) Module {
+		return &dorkModule{
+			// Google is queried rather than the target, so this counts as passive
+			base: base{
+				name:        "dork",
+				description: "Performing Google dorking",
+				passive:     true,
+				scope:       ScopeApex,
+				timeout:     3 * time.Minute,
+			},
+			queriesFile: cfg.QueriesFile,
+		}
+	})
+}
+
+// DorkResults lists the search URL built for every dork query
+type DorkResults []model.DorkResult
+
+// Apply implements Result
+func (d DorkResults) Apply(r *model.DomainReport) {
+	r.Dorks = d
+}
+
+type dorkModule struct {
+	base
+	queriesFile string
+}
+
+func (m *dorkModule) Run(ctx context.Context, t Target) (Result, error) {
+	queries, err := dork.LoadDorkQueries(m.queriesFile)
+	if err != nil {
+		return nil, err
+	}
+	results, err := dork.PerformDorkSearch(ctx, t.Name(m.Scope()), queries)
+	return DorkResults(results), err
+}
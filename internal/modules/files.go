@@ -0,0 +1,82 @@
+package modules
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/qepting91/gomain_analysis/internal/evidence"
+	"github.com/qepting91/gomain_analysis/internal/fetcher"
+	"github.com/qepting91/gomain_analysis/internal/model"
+	"github.com/qepting91/gomain_analysis/internal/scope"
+	"github.com/qepting91/gomain_analysis/internal/settings"
+)
+
+func init() {
+	Register("files", func(cfg *settings.Config) Module {
+		webFetcher := fetcher.NewWebFetcher()
+		webFetcher.CommonPaths = cfg.Web.CommonFiles
+		return &filesModule{
+			base: base{
+				name:        "files",
+				description: "Probing for commonly exposed files",
+				timeout:     60 * time.Second,
+			},
+			fetcher: webFetcher,
+		}
+	})
+}
+
+// FilesResult holds the common files the site served
+type FilesResult struct {
+	Files     []model.CommonFile
+	NotProbed []model.NotProbed
+}
+
+// Apply implements Result
+func (f FilesResult) Apply(r *model.DomainReport) {
+	r.Files = f.Files
+	r.NotProbed = append(r.NotProbed, f.NotProbed...)
+}
+
+type filesModule struct {
+	base
+	fetcher *fetcher.WebFetcher
+}
+
+func (m *filesModule) Run(ctx context.Context, t Target) (Result, error) {
+	var res FilesResult
+	if err := scope.Check(t.Host); err != nil {
+		res.NotProbed = append(res.NotProbed, notProbed(m.name, t.Host, "scan target", err))
+		return res, nil
+	}
+	for _, path := range m.fetcher.CommonPaths {
+		url := fmt.Sprintf("https://%s%s", t.Host, path)
+		fetchCtx, tracker := evidence.Track(ctx)
+		content, err := m.fetcher.FetchWebContent(fetchCtx, url)
+		if errors.Is(err, scope.ErrOutOfScope) {
+			res.NotProbed = append(res.NotProbed, notProbed(m.name, url, "redirect from "+url, err))
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				break
+			}
+			continue
+		}
+		file := model.CommonFile{
+			Path:     path,
+			URL:      url,
+			Size:     len(content),
+			Evidence: tracker.IDs(),
+		}
+		if len(content) > model.MaxFileContent {
+			content = content[:model.MaxFileContent]
+		}
+		file.Content = content
+		res.Files = append(res.Files, file)
+	}
+	sort.Slice(res.Files, func(i, j int) bool { return res.Files[i].Path < res.Files[j].Path })
+	return res, ctx.Err()
+}
@@ -0,0 +1,51 @@
+package modules
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/qepting91/gomain_analysis/internal/config"
+	"github.com/qepting91/gomain_analysis/internal/geolocation"
+	"github.com/qepting91/gomain_analysis/internal/model"
+	"github.com/qepting91/gomain_analysis/internal/settings"
+)
+
+func init() {
+	Register("geo", func(*settings.Config) Module {
+		return &geoModule{base{
+			name:        "geo",
+			description: "Fetching geolocation information",
+			deps:        []string{"dns"},
+			passive:     true,
+			timeout:     10 * time.Second,
+		}}
+	})
+}
+
+// GeoResult holds the location of each resolved IP
+type GeoResult []model.GeoLocation
+
+// Apply implements Result
+func (g GeoResult) Apply(r *model.DomainReport) {
+	r.Geo = g
+}
+
+type geoModule struct{ base }
+
+// Run looks up every A record in the local GeoLite2 database
+func (m *geoModule) Run(ctx context.Context, t Target) (Result, error) {
+	if err := geolite.Initialize(); err != nil {
+		return nil, err
+	}
+	var res GeoResult
+	for _, ip := range t.Report.DNS.ARecords {
+		geoInfo, err := geolocation.LookupGeolocation(ip)
+		if err != nil {
+			log.Printf("Error getting geolocation for IP %s: %v", ip, err)
+			continue
+		}
+		res = append(res, geolocation.NewGeoLocation(ip, geoInfo))
+	}
+	return res, nil
+}
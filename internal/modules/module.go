@@ -0,0 +1,164 @@
+package modules
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/qepting91/gomain_analysis/internal/model"
+	"github.com/qepting91/gomain_analysis/internal/scope"
+	"github.com/qepting91/gomain_analysis/internal/settings"
+)
+
+// Scope says which name of the target a module works on
+type Scope int
+
+const (
+	// ScopeHost is the exact host that was given, e.g. www.example.co.uk
+	ScopeHost Scope = iota
+	// ScopeApex is its registrable domain, e.g. example.co.uk
+	ScopeApex
+)
+
+func (s Scope) String() string {
+	if s == ScopeApex {
+		return "apex"
+	}
+	return "host"
+}
+
+// Target is what a module analyzes
+type Target struct {
+	model.Target
+	// Report holds whatever the module's dependencies found. Modules may read
+	// it but must never modify it; changes go through Result.Apply.
+	Report *model.DomainReport
+}
+
+// Name returns the host or the registrable domain depending on scope. IP
+// targets have no registrable domain, so they always return the host.
+func (t Target) Name(s Scope) string {
+	if s == ScopeApex && t.Apex != "" {
+		return t.Apex
+	}
+	return t.Host
+}
+
+// Result is a module's contribution to the report
+type Result interface {
+	// Apply copies the result into the section of r owned by the module
+	Apply(r *model.DomainReport)
+}
+
+// Module is a single analysis source
+type Module interface {
+	// Name is the short identifier used by --modules, --skip and profiles
+	Name() string
+	// Description is shown as progress while the module runs
+	Description() string
+	// Dependencies names the modules whose results Run reads from the target
+	Dependencies() []string
+	// Passive reports whether the module avoids sending traffic to the target
+	Passive() bool
+	// Scope says whether the module looks up the host or its registrable domain
+	Scope() Scope
+	// Timeout is the default deadline for Run
+	Timeout() time.Duration
+	// Run performs the lookup. It may return a partial result together with
+	// an error, e.g. when its deadline is reached halfway through.
+	Run(ctx context.Context, t Target) (Result, error)
+}
+
+// Factory builds a module from the runtime configuration
+type Factory func(cfg *settings.Config) Module
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]Factory)
+)
+
+// Register makes a module available under name. It panics if the name is
+// taken, which can only happen through a programming error.
+func Register(name string, f Factory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if _, exists := registry[name]; exists {
+		panic(fmt.Sprintf("modules: %q registered twice", name))
+	}
+	registry[name] = f
+}
+
+// Names returns the registered module names in sorted order
+func Names() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// New instantiates every registered module, sorted by name. A nil cfg uses
+// the built-in defaults.
+func New(cfg *settings.Config) []Module {
+	if cfg == nil {
+		cfg = settings.Default()
+	}
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	mods := make([]Module, 0, len(registry))
+	for _, f := range registry {
+		mods = append(mods, f(cfg))
+	}
+	sort.Slice(mods, func(i, j int) bool { return mods[i].Name() < mods[j].Name() })
+	return mods
+}
+
+// Get instantiates the module registered under name
+func Get(name string, cfg *settings.Config) (Module, error) {
+	registryMu.RLock()
+	f, ok := registry[name]
+	registryMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown module %q", name)
+	}
+	if cfg == nil {
+		cfg = settings.Default()
+	}
+	return f(cfg), nil
+}
+
+// base carries the static description shared by every module
+type base struct {
+	name        string
+	description string
+	deps        []string
+	passive     bool
+	scope       Scope
+	timeout     time.Duration
+}
+
+func (b base) Name() string           { return b.name }
+func (b base) Description() string    { return b.description }
+func (b base) Dependencies() []string { return b.deps }
+func (b base) Passive() bool          { return b.passive }
+func (b base) Scope() Scope           { return b.scope }
+func (b base) Timeout() time.Duration { return b.timeout }
+
+// notProbed records a host that a module left alone because of the scope.
+// The host named by a scope error wins over host, which only says what was
+// asked for.
+func notProbed(module, host, found string, err error) model.NotProbed {
+	np := model.NotProbed{Target: host, Module: module, Found: found, Reason: err.Error()}
+	var scopeErr *scope.Error
+	if errors.As(err, &scopeErr) {
+		np.Target = scopeErr.Host
+		np.Reason = scopeErr.Reason
+	}
+	return np
+}
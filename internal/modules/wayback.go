@@ -0,0 +1,36 @@
+package modules
+
+import (
+	"context"
+	"time"
+
+	"github.com/qepting91/gomain_analysis/internal/model"
+	"github.com/qepting91/gomain_analysis/internal/settings"
+	"github.com/qepting91/gomain_analysis/internal/wayback"
+)
+
+func init() {
+	Register("wayback", func(*settings.Config) Module {
+		return &waybackModule{base{
+			name:        "wayback",
+			description: "Fetching Wayback Machine snapshots",
+			passive:     true,
+			timeout:     30 * time.Second,
+		}}
+	})
+}
+
+// WaybackResult lists the archived snapshots of the domain
+type WaybackResult []model.WaybackSnapshot
+
+// Apply implements Result
+func (w WaybackResult) Apply(r *model.DomainReport) {
+	r.Wayback = w
+}
+
+type waybackModule struct{ base }
+
+func (m *waybackModule) Run(ctx context.Context, t Target) (Result, error) {
+	snapshots, err := wayback.FetchSnapshots(ctx, t.Name(m.Scope()))
+	return WaybackResult(snapshots), err
+}
@@ -0,0 +1,71 @@
+package modules
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/qepting91/gomain_analysis/internal/evidence"
+	"github.com/qepting91/gomain_analysis/internal/fetcher"
+	"github.com/qepting91/gomain_analysis/internal/model"
+	"github.com/qepting91/gomain_analysis/internal/parser"
+	"github.com/qepting91/gomain_analysis/internal/scope"
+	"github.com/qepting91/gomain_analysis/internal/settings"
+)
+
+func init() {
+	Register("web", func(cfg *settings.Config) Module {
+		webFetcher := fetcher.NewWebFetcher()
+		webFetcher.CommonPaths = cfg.Web.CommonFiles
+		return &webModule{
+			base: base{
+				name:        "web",
+				description: "Fetching and parsing website content",
+				timeout:     30 * time.Second,
+			},
+			fetcher: webFetcher,
+		}
+	})
+}
+
+// WebResult is the fetched home page and what the parser extracted from it
+type WebResult struct {
+	model.WebInfo
+	// NotProbed holds the page, or the redirect target, when it is out of scope
+	NotProbed []model.NotProbed
+}
+
+// Apply implements Result
+func (w *WebResult) Apply(r *model.DomainReport) {
+	r.Web = w.WebInfo
+	r.NotProbed = append(r.NotProbed, w.NotProbed...)
+}
+
+type webModule struct {
+	base
+	fetcher *fetcher.WebFetcher
+}
+
+func (m *webModule) Run(ctx context.Context, t Target) (Result, error) {
+	url := t.URL
+	if url == "" {
+		url = "https://" + t.Host
+	}
+	res := &WebResult{WebInfo: model.WebInfo{URL: url}}
+	fetchCtx, tracker := evidence.Track(ctx)
+	content, err := m.fetcher.FetchWebContent(fetchCtx, res.URL)
+	res.Evidence = tracker.IDs()
+	if errors.Is(err, scope.ErrOutOfScope) {
+		found := "scan target"
+		var scopeErr *scope.Error
+		if errors.As(err, &scopeErr) && scopeErr.Host != t.Host {
+			found = "redirect from " + url
+		}
+		res.NotProbed = append(res.NotProbed, notProbed(m.name, url, found, err))
+	}
+	if err != nil {
+		return res, err
+	}
+	res.Content, err = parser.ParseHTMLContent(content)
+	return res, err
+}
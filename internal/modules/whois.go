@@ -0,0 +1,46 @@
+package modules
+
+import (
+	"context"
+	"time"
+
+	"github.com/qepting91/gomain_analysis/internal/evidence"
+	"github.com/qepting91/gomain_analysis/internal/model"
+	"github.com/qepting91/gomain_analysis/internal/settings"
+	"github.com/qepting91/gomain_analysis/internal/whois"
+)
+
+func init() {
+	Register("whois", func(*settings.Config) Module {
+		return &whoisModule{base{
+			name:        "whois",
+			description: "Fetching WHOIS information",
+			passive:     true,
+			scope:       ScopeApex,
+			timeout:     30 * time.Second,
+		}}
+	})
+}
+
+// WHOISResult is the parsed registration record
+type WHOISResult struct {
+	model.WHOISInfo
+}
+
+// Apply implements Result
+func (w *WHOISResult) Apply(r *model.DomainReport) {
+	r.WHOIS = w.WHOISInfo
+}
+
+type whoisModule struct{ base }
+
+func (m *whoisModule) Run(ctx context.Context, t Target) (Result, error) {
+	lookupCtx, tracker := evidence.Track(ctx)
+	raw, err := whois.LookupWHOIS(lookupCtx, t.Name(m.Scope()))
+	if err != nil {
+		return nil, err
+	}
+	info := whois.Parse(raw)
+	info.Evidence = tracker.IDs()
+	return &WHOISResult{info}, nil
+}
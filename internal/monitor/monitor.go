@@ -0,0 +1,146 @@
+package monitor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/qepting91/gomain_analysis/internal/alert"
+	"github.com/qepting91/gomain_analysis/internal/diff"
+	"github.com/qepting91/gomain_analysis/internal/model"
+	"github.com/qepting91/gomain_analysis/internal/pipeline"
+	"github.com/qepting91/gomain_analysis/internal/store"
+)
+
+// Monitor re-scans a watch list on a schedule and raises alerts when a
+// domain changed since its previous stored scan
+type Monitor struct {
+	Selection *pipeline.Selection
+	// OpenStore opens the scan history. The store is held only while a
+	// previous scan is read or a new one saved, so other commands can use it
+	// while the monitor waits or scans.
+	OpenStore func() (*store.Store, error)
+	Notifiers []alert.Notifier
+	// Timeout bounds each domain's scan; zero means no limit
+	Timeout  time.Duration
+	Progress io.Writer
+}
+
+// Run scans the watch list returned by domains every interval until ctx is
+// cancelled. The list is reloaded each cycle so edits take effect without a
+// restart; if it cannot be read, the previous list is scanned again.
+func (m *Monitor) Run(ctx context.Context, domains func() ([]string, error), interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	var list []string
+	for {
+		if latest, err := domains(); err != nil {
+			log.Printf("Error reloading watch list, keeping the previous one: %v", err)
+		} else {
+			list = latest
+		}
+		m.RunOnce(ctx, list)
+		m.logf("Next check at %s", time.Now().Add(interval).Format(time.RFC3339))
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce scans every domain once, stores the results and alerts on changes
+func (m *Monitor) RunOnce(ctx context.Context, domains []string) {
+	for _, domain := range domains {
+		if ctx.Err() != nil {
+			return
+		}
+		if err := m.check(ctx, domain); err != nil {
+			log.Printf("Error monitoring %s: %v", domain, err)
+		}
+	}
+}
+
+func (m *Monitor) check(ctx context.Context, domain string) error {
+	previous, err := m.previous(domain)
+	if err != nil {
+		return err
+	}
+
+	scanCtx := ctx
+	if m.Timeout > 0 {
+		var cancel context.CancelFunc
+		scanCtx, cancel = context.WithTimeout(ctx, m.Timeout)
+		defer cancel()
+	}
+	current, err := pipeline.Analyze(scanCtx, domain, m.Selection, nil)
+	if err != nil {
+		return err
+	}
+	if current.Interrupted {
+		// A cut-short scan would look like everything disappeared
+		m.logf("%s: scan interrupted, not stored", domain)
+		return nil
+	}
+	if err := m.save(current); err != nil {
+		return err
+	}
+
+	if previous == nil {
+		m.logf("%s: baseline recorded", domain)
+		return nil
+	}
+	d, err := diff.Compare(previous, current)
+	if err != nil {
+		return err
+	}
+	if !d.HasChanges() {
+		m.logf("%s: no changes", domain)
+		return nil
+	}
+
+	a := alert.New(d)
+	m.logf("%s: %s", domain, strings.Join(a.Summary, "; "))
+	for _, n := range m.Notifiers {
+		if err := n.Notify(ctx, a); err != nil {
+			log.Printf("Error sending alert for %s: %v", domain, err)
+		}
+	}
+	return nil
+}
+
+// previous returns the latest stored scan of domain, or nil if there is none
+func (m *Monitor) previous(domain string) (*model.DomainReport, error) {
+	s, err := m.OpenStore()
+	if err != nil {
+		return nil, err
+	}
+	defer s.Close()
+	r, _, err := s.Get(domain, 0)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, nil
+	}
+	return r, err
+}
+
+func (m *Monitor) save(r *model.DomainReport) error {
+	s, err := m.OpenStore()
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+	_, err = s.Save(r)
+	return err
+}
+
+func (m *Monitor) logf(format string, args ...interface{}) {
+	if m.Progress == nil {
+		return
+	}
+	fmt.Fprintf(m.Progress, "[%s] %s\n", time.Now().Format("15:04:05"), fmt.Sprintf(format, args...))
+}
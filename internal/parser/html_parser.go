@@ -4,29 +4,31 @@ import (
 	"fmt"
 	"log"
 	"net/url"
+	"sort"
 	"strings"
 
 	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
 )
 
 type ParsedContent struct {
-	Title         string
-	MetaTags      map[string]string
-	Links         []string
-	ExternalLinks []string
-	InternalLinks []string
-	Emails        []string
-	PhoneNumbers  []string
-	SocialMedia   map[string][]string
-	Technologies  []string
-	Scripts       []string
-	StyleSheets   []string
-	Forms         []string
-	Comments      []string
+	Title         string              `json:"title"`
+	MetaTags      map[string]string   `json:"meta_tags"`
+	Links         []string            `json:"links"`
+	ExternalLinks []string            `json:"external_links"`
+	InternalLinks []string            `json:"internal_links"`
+	Emails        []string            `json:"emails"`
+	PhoneNumbers  []string            `json:"phone_numbers"`
+	SocialMedia   map[string][]string `json:"social_media"`
+	Technologies  []string            `json:"technologies"`
+	Scripts       []string            `json:"scripts"`
+	StyleSheets   []string            `json:"stylesheets"`
+	Forms         []string            `json:"forms"`
+	Comments      []string            `json:"comments"`
 }
 
-func ParseHTMLContent(html string) (*ParsedContent, error) {
-	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+func ParseHTMLContent(content string) (*ParsedContent, error) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(content))
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse HTML content: %v", err)
 	}
@@ -98,8 +100,9 @@ func ParseHTMLContent(html string) (*ParsedContent, error) {
 
 	// Extract HTML comments
 	doc.Find("*").Contents().Each(func(_ int, s *goquery.Selection) {
-		if s.Length() > 0 && s.Get(0).Data == "#comment" {
-			parsed.Comments = append(parsed.Comments, strings.TrimSpace(s.Text()))
+		// A comment node's Data is the comment text itself
+		if s.Length() > 0 && s.Get(0).Type == html.CommentNode {
+			parsed.Comments = append(parsed.Comments, strings.TrimSpace(s.Get(0).Data))
 		}
 	})
 
@@ -148,6 +151,8 @@ func detectTechnologies(doc *goquery.Document) []string {
 			technologies = append(technologies, tech)
 		}
 	}
+	// Sorted so that the same page always gives the same report
+	sort.Strings(technologies)
 
 	return technologies
 }
@@ -0,0 +1,196 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/qepting91/gomain_analysis/internal/audit"
+	"github.com/qepting91/gomain_analysis/internal/evidence"
+	"github.com/qepting91/gomain_analysis/internal/findings"
+	"github.com/qepting91/gomain_analysis/internal/model"
+	"github.com/qepting91/gomain_analysis/internal/target"
+)
+
+// Step is a single analysis module run by the Pipeline.
+// Run must only write the section of the report owned by the step, and may
+// read sections written by the steps listed in DependsOn.
+type Step struct {
+	Name      string
+	Title     string
+	DependsOn []string
+	// Passive steps do not send traffic to the target itself
+	Passive bool
+	Timeout time.Duration
+	Run     func(ctx context.Context, r *model.DomainReport) error
+}
+
+// Pipeline runs independent steps in parallel and dependent steps once
+// everything they depend on has finished
+type Pipeline struct {
+	steps    []Step
+	progress io.Writer
+	mu       sync.Mutex
+}
+
+// New validates the steps and returns a Pipeline that reports progress to w
+func New(steps []Step, w io.Writer) (*Pipeline, error) {
+	byName := make(map[string]Step, len(steps))
+	for _, step := range steps {
+		if _, exists := byName[step.Name]; exists {
+			return nil, fmt.Errorf("duplicate pipeline step %q", step.Name)
+		}
+		byName[step.Name] = step
+	}
+
+	// Reject dependency cycles, which would otherwise deadlock Run
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make(map[string]int, len(steps))
+	var visit func(name string) error
+	visit = func(name string) error {
+		switch state[name] {
+		case visiting:
+			return fmt.Errorf("dependency cycle detected at step %q", name)
+		case visited:
+			return nil
+		}
+		state[name] = visiting
+		for _, dep := range byName[name].DependsOn {
+			if _, ok := byName[dep]; !ok {
+				continue
+			}
+			if err := visit(dep); err != nil {
+				return err
+			}
+		}
+		state[name] = visited
+		return nil
+	}
+	for _, step := range steps {
+		if err := visit(step.Name); err != nil {
+			return nil, err
+		}
+	}
+
+	if w == nil {
+		w = io.Discard
+	}
+	return &Pipeline{steps: steps, progress: w}, nil
+}
+
+// Run executes every step and returns once all of them have finished, timed
+// out or been cancelled. The outcome of each step is recorded in r.Modules, so
+// the report is usable even when ctx is cancelled part way through.
+func (p *Pipeline) Run(ctx context.Context, r *model.DomainReport) {
+	done := make(map[string]chan struct{}, len(p.steps))
+	for _, step := range p.steps {
+		done[step.Name] = make(chan struct{})
+	}
+
+	statuses := make([]model.ModuleStatus, len(p.steps))
+	var wg sync.WaitGroup
+	for i, step := range p.steps {
+		wg.Add(1)
+		go func(i int, step Step) {
+			defer wg.Done()
+			defer close(done[step.Name])
+			statuses[i] = p.runStep(ctx, step, r, done)
+		}(i, step)
+	}
+	wg.Wait()
+
+	r.Modules = append(r.Modules, statuses...)
+	r.Interrupted = ctx.Err() != nil
+}
+
+func (p *Pipeline) runStep(ctx context.Context, step Step, r *model.DomainReport, done map[string]chan struct{}) model.ModuleStatus {
+	status := model.ModuleStatus{Name: step.Name}
+
+	// Wait for dependencies; steps that are not part of this pipeline are ignored
+	for _, dep := range step.DependsOn {
+		ch, ok := done[dep]
+		if !ok {
+			continue
+		}
+		select {
+		case <-ch:
+		case <-ctx.Done():
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		status.Status = model.StatusCancelled
+		status.Error = err.Error()
+		p.logf("[%s] cancelled before start", step.Name)
+		return status
+	}
+
+	stepCtx := ctx
+	if step.Timeout > 0 {
+		var cancel context.CancelFunc
+		stepCtx, cancel = context.WithTimeout(ctx, step.Timeout)
+		defer cancel()
+	}
+
+	// Whatever raw evidence the step saves, and every network action it
+	// takes, is attributed to it
+	stepCtx = audit.WithModule(stepCtx, step.Name)
+	stepCtx, tracker := evidence.Track(stepCtx)
+
+	p.logf("[%s] %s", step.Name, step.Title)
+	status.Started = time.Now().UTC()
+	err := step.Run(stepCtx, r)
+	status.Duration = time.Since(status.Started)
+	status.Evidence = tracker.IDs()
+
+	switch {
+	case err == nil:
+		status.Status = model.StatusCompleted
+	case ctx.Err() != nil:
+		status.Status = model.StatusCancelled
+	case errors.Is(stepCtx.Err(), context.DeadlineExceeded):
+		status.Status = model.StatusTimeout
+	default:
+		status.Status = model.StatusFailed
+	}
+	if err != nil {
+		status.Error = err.Error()
+	}
+
+	p.logf("[%s] %s in %s", step.Name, status.Status, status.Duration.Round(time.Millisecond))
+	return status
+}
+
+func (p *Pipeline) logf(format string, args ...interface{}) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	fmt.Fprintf(p.progress, format+"\n", args...)
+}
+
+// Analyze runs the selected steps against domain and returns the report with
+// the findings of every rule whose modules completed. The report is partial
+// if ctx was cancelled.
+func Analyze(ctx context.Context, domain string, sel *Selection, w io.Writer) (*model.DomainReport, error) {
+	t, err := target.Parse(domain)
+	if err != nil {
+		return nil, err
+	}
+	p, err := New(sel.Steps, w)
+	if err != nil {
+		return nil, err
+	}
+
+	r := model.NewDomainReport(t.Host)
+	r.Target = t
+	r.Profile = sel.Profile
+	r.Skipped = sel.Skipped
+	p.Run(ctx, r)
+	r.Findings = findings.Evaluate(r, time.Now())
+	return r, nil
+}
@@ -0,0 +1,83 @@
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/qepting91/gomain_analysis/internal/model"
+)
+
+func TestNewRejectsInvalidSteps(t *testing.T) {
+	tests := []struct {
+		name  string
+		steps []Step
+	}{
+		{"duplicate", []Step{{Name: "dns"}, {Name: "dns"}}},
+		{"cycle", []Step{{Name: "a", DependsOn: []string{"b"}}, {Name: "b", DependsOn: []string{"c"}}, {Name: "c", DependsOn: []string{"a"}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := New(tt.steps, nil); err == nil {
+				t.Error("New returned no error")
+			}
+		})
+	}
+}
+
+func TestRunOrdersDependencies(t *testing.T) {
+	var mu sync.Mutex
+	var order []string
+	step := func(name string, deps ...string) Step {
+		return Step{Name: name, DependsOn: deps, Run: func(ctx context.Context, r *model.DomainReport) error {
+			mu.Lock()
+			defer mu.Unlock()
+			order = append(order, name)
+			return nil
+		}}
+	}
+	// "missing" is not part of the pipeline and must not block "geo"
+	p, err := New([]Step{step("geo", "dns", "missing"), step("reverse", "dns"), step("report", "geo", "reverse"), step("dns")}, nil)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	r := model.NewDomainReport("example.com")
+	p.Run(context.Background(), r)
+
+	position := make(map[string]int, len(order))
+	for i, name := range order {
+		position[name] = i
+	}
+	for _, edge := range [][2]string{{"dns", "geo"}, {"dns", "reverse"}, {"geo", "report"}, {"reverse", "report"}} {
+		if position[edge[0]] > position[edge[1]] {
+			t.Errorf("%s ran before its dependency %s: %v", edge[1], edge[0], order)
+		}
+	}
+	for _, status := range r.Modules {
+		if status.Status != model.StatusCompleted {
+			t.Errorf("%s: status %s", status.Name, status.Status)
+		}
+	}
+}
+
+func TestRunRecordsFailures(t *testing.T) {
+	p, err := New([]Step{
+		{Name: "dns", Run: func(ctx context.Context, r *model.DomainReport) error { return errors.New("no answer") }},
+		{Name: "geo", DependsOn: []string{"dns"}, Run: func(ctx context.Context, r *model.DomainReport) error { return nil }},
+	}, nil)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	r := model.NewDomainReport("example.com")
+	p.Run(context.Background(), r)
+
+	if r.ModuleCompleted("dns") {
+		t.Error("failed dns step reported as completed")
+	}
+	// A dependent still runs once its dependency has finished, whatever
+	// the outcome, and works with whatever data is there
+	if !r.ModuleCompleted("geo") {
+		t.Error("geo did not run after dns failed")
+	}
+}
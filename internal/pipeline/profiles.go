@@ -0,0 +1,132 @@
+package pipeline
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Scan profiles selectable with --profile
+const (
+	ProfilePassive    = "passive"
+	ProfileStandard   = "standard"
+	ProfileAggressive = "aggressive"
+	ProfileCustom     = "custom"
+)
+
+// DefaultProfile is used when no profile or module list is given
+const DefaultProfile = ProfileStandard
+
+// profiles maps each named profile to the modules it runs. Passive never
+// contacts the target's own web server; aggressive adds the common file
+// probes and the Google dork queries.
+var profiles = map[string][]string{
+	ProfilePassive:    {"crt", "dns", "reverse", "whois", "wayback", "geo"},
+	ProfileStandard:   {"crt", "dns", "reverse", "whois", "web", "wayback", "geo"},
+	ProfileAggressive: {"crt", "dns", "reverse", "whois", "web", "files", "wayback", "dork", "geo"},
+}
+
+// Profiles returns the names of the built-in profiles
+func Profiles() []string {
+	return []string{ProfilePassive, ProfileStandard, ProfileAggressive}
+}
+
+// ProfileModules returns the modules run by the named profile
+func ProfileModules(profile string) ([]string, error) {
+	modules, ok := profiles[strings.ToLower(profile)]
+	if !ok {
+		return nil, fmt.Errorf("unknown profile %q (available: %s)", profile, strings.Join(Profiles(), ", "))
+	}
+	return modules, nil
+}
+
+// Selection describes which steps were chosen for a scan
+type Selection struct {
+	Profile string
+	Steps   []Step
+	Skipped []string
+}
+
+// Select narrows steps down to the given profile, or to modules when it is
+// non-empty, minus anything in skip. Dependencies of selected modules are
+// added automatically; modules whose dependencies were skipped are dropped.
+func Select(steps []Step, profile string, modules, skip []string) (*Selection, error) {
+	byName := make(map[string]Step, len(steps))
+	for _, step := range steps {
+		byName[step.Name] = step
+	}
+	validate := func(names []string) error {
+		for _, name := range names {
+			if _, ok := byName[name]; !ok {
+				return fmt.Errorf("unknown module %q (available: %s)", name, strings.Join(StepNames(steps), ", "))
+			}
+		}
+		return nil
+	}
+
+	sel := &Selection{Profile: strings.ToLower(profile)}
+	wanted := modules
+	if len(modules) > 0 {
+		sel.Profile = ProfileCustom
+	} else {
+		if sel.Profile == "" {
+			sel.Profile = DefaultProfile
+		}
+		var err error
+		if wanted, err = ProfileModules(sel.Profile); err != nil {
+			return nil, err
+		}
+	}
+	if err := validate(wanted); err != nil {
+		return nil, err
+	}
+	if err := validate(skip); err != nil {
+		return nil, err
+	}
+
+	skipped := make(map[string]bool, len(skip))
+	for _, name := range skip {
+		skipped[name] = true
+	}
+
+	// Resolve dependencies, dropping modules that need something skipped
+	selected := make(map[string]bool)
+	var include func(name string) bool
+	include = func(name string) bool {
+		if skipped[name] {
+			return false
+		}
+		for _, dep := range byName[name].DependsOn {
+			if !include(dep) {
+				return false
+			}
+		}
+		selected[name] = true
+		return true
+	}
+	for _, name := range wanted {
+		include(name)
+	}
+
+	for _, step := range steps {
+		if selected[step.Name] {
+			sel.Steps = append(sel.Steps, step)
+		} else {
+			sel.Skipped = append(sel.Skipped, step.Name)
+		}
+	}
+	if len(sel.Steps) == 0 {
+		return nil, fmt.Errorf("no modules selected")
+	}
+	return sel, nil
+}
+
+// StepNames returns the names of the given steps in sorted order
+func StepNames(steps []Step) []string {
+	names := make([]string, 0, len(steps))
+	for _, step := range steps {
+		names = append(names, step.Name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,73 @@
+package pipeline
+
+import (
+	"strings"
+	"testing"
+)
+
+// testSteps mirrors the registered modules and their dependencies
+func testSteps() []Step {
+	deps := map[string][]string{"reverse": {"dns"}, "geo": {"dns"}}
+	var steps []Step
+	for _, name := range []string{"crt", "dns", "reverse", "whois", "web", "files", "wayback", "dork", "geo"} {
+		steps = append(steps, Step{Name: name, DependsOn: deps[name]})
+	}
+	return steps
+}
+
+func TestSelect(t *testing.T) {
+	tests := []struct {
+		name        string
+		profile     string
+		modules     []string
+		skip        []string
+		wantProfile string
+		wantSteps   string
+	}{
+		{"default profile", "", nil, nil, ProfileStandard, "crt,dns,geo,reverse,wayback,web,whois"},
+		{"passive profile", "Passive", nil, nil, ProfilePassive, "crt,dns,geo,reverse,wayback,whois"},
+		{"aggressive profile", ProfileAggressive, nil, nil, ProfileAggressive, "crt,dns,dork,files,geo,reverse,wayback,web,whois"},
+		{"modules override the profile", ProfilePassive, []string{"web"}, nil, ProfileCustom, "web"},
+		{"dependencies are added", "", []string{"geo"}, nil, ProfileCustom, "dns,geo"},
+		{"skip a module", "", nil, []string{"wayback"}, ProfileStandard, "crt,dns,geo,reverse,web,whois"},
+		{"skipping a dependency drops its dependents", "", nil, []string{"dns"}, ProfileStandard, "crt,wayback,web,whois"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sel, err := Select(testSteps(), tt.profile, tt.modules, tt.skip)
+			if err != nil {
+				t.Fatalf("Select: %v", err)
+			}
+			if sel.Profile != tt.wantProfile {
+				t.Errorf("Profile = %s, want %s", sel.Profile, tt.wantProfile)
+			}
+			if got := strings.Join(StepNames(sel.Steps), ","); got != tt.wantSteps {
+				t.Errorf("Steps = %s, want %s", got, tt.wantSteps)
+			}
+			if len(sel.Steps)+len(sel.Skipped) != len(testSteps()) {
+				t.Errorf("%d steps selected and %d skipped, want %d in all", len(sel.Steps), len(sel.Skipped), len(testSteps()))
+			}
+		})
+	}
+}
+
+func TestSelectInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		profile string
+		modules []string
+		skip    []string
+	}{
+		{"unknown profile", "stealth", nil, nil},
+		{"unknown module", "", []string{"nmap"}, nil},
+		{"unknown skipped module", "", nil, []string{"nmap"}},
+		{"nothing left", "", []string{"geo"}, []string{"dns"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Select(testSteps(), tt.profile, tt.modules, tt.skip); err == nil {
+				t.Error("Select returned no error")
+			}
+		})
+	}
+}
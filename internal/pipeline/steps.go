@@ -0,0 +1,49 @@
+package pipeline
+
+import (
+	"context"
+	"sync"
+
+	"github.com/qepting91/gomain_analysis/internal/model"
+	"github.com/qepting91/gomain_analysis/internal/modules"
+	"github.com/qepting91/gomain_analysis/internal/settings"
+)
+
+// DefaultSteps returns a step for every registered module, tuned by cfg
+// (nil means the built-in defaults)
+func DefaultSteps(cfg *settings.Config) []Step {
+	if cfg == nil {
+		cfg = settings.Default()
+	}
+	steps := FromModules(modules.New(cfg))
+	for i := range steps {
+		steps[i].Timeout = cfg.Timeout(steps[i].Name, steps[i].Timeout)
+	}
+	return steps
+}
+
+// FromModules wraps modules as pipeline steps. Results are applied to the
+// report one at a time, so modules never write to it concurrently.
+func FromModules(mods []modules.Module) []Step {
+	var mu sync.Mutex
+	steps := make([]Step, 0, len(mods))
+	for _, m := range mods {
+		steps = append(steps, Step{
+			Name:      m.Name(),
+			Title:     m.Description(),
+			DependsOn: m.Dependencies(),
+			Passive:   m.Passive(),
+			Timeout:   m.Timeout(),
+			Run: func(ctx context.Context, r *model.DomainReport) error {
+				res, err := m.Run(ctx, modules.Target{Target: r.Target, Report: r})
+				if res != nil {
+					mu.Lock()
+					res.Apply(r)
+					mu.Unlock()
+				}
+				return err
+			},
+		})
+	}
+	return steps
+}
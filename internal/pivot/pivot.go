@@ -0,0 +1,221 @@
+package pivot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/qepting91/gomain_analysis/internal/model"
+	"github.com/qepting91/gomain_analysis/internal/scope"
+	"github.com/qepting91/gomain_analysis/internal/target"
+)
+
+// Reasons a lead was found
+const (
+	ReasonCertificate  = "certificate SAN"
+	ReasonReverseDNS   = "reverse DNS"
+	ReasonMX           = "MX record"
+	ReasonExternalLink = "external link"
+	ReasonARecord      = "A record"
+)
+
+// DefaultModules are run against pivots unless configured otherwise
+var DefaultModules = []string{"dns", "web", "geo"}
+
+// Lead is a host or IP found in a report that could be analyzed in turn
+type Lead struct {
+	Target   string
+	IsIP     bool
+	Reason   string
+	Evidence string
+}
+
+// Leads extracts the hosts and IPs mentioned in r, once each, in the order
+// of the sections they came from
+func Leads(r *model.DomainReport) []Lead {
+	var leads []Lead
+	seen := make(map[string]bool)
+	add := func(name, reason, evidence string) {
+		name = strings.TrimSpace(name)
+		if name == "" || strings.HasPrefix(name, "*") {
+			return
+		}
+		t, err := target.Parse(name)
+		if err != nil || seen[t.Host] {
+			return
+		}
+		seen[t.Host] = true
+		leads = append(leads, Lead{Target: t.Host, IsIP: t.IsIP, Reason: reason, Evidence: evidence})
+	}
+
+	for _, entry := range r.CTLogs {
+		for _, name := range strings.Split(entry.NameValue, "\n") {
+			add(name, ReasonCertificate, "crt.sh #"+strconv.Itoa(entry.MinCertID))
+		}
+	}
+	for _, cert := range r.Certificates {
+		for _, name := range cert.DNSNames {
+			add(name, ReasonCertificate, "crt.sh #"+strconv.Itoa(cert.ID))
+		}
+	}
+	for _, mx := range r.DNS.MXRecords {
+		add(mx.Host, ReasonMX, mx.String())
+	}
+	for _, ip := range r.DNS.ARecords {
+		for _, name := range r.DNS.ReverseDNS[ip] {
+			add(name, ReasonReverseDNS, ip)
+		}
+	}
+	if r.Web.Content != nil {
+		for _, link := range r.Web.Content.ExternalLinks {
+			if u, err := url.Parse(link); err == nil && u.Hostname() != "" {
+				add(u.Hostname(), ReasonExternalLink, link)
+			}
+		}
+	}
+	for _, ip := range r.DNS.ARecords {
+		add(ip, ReasonARecord, "")
+	}
+	return leads
+}
+
+// Scope decides which leads are followed
+type Scope struct {
+	// Domains lists the domains whose names, including subdomains, are in scope
+	Domains []string
+	// IPs allows pivoting to addresses found in in-scope reports
+	IPs bool
+}
+
+// Allows reports whether the lead is in scope
+func (s Scope) Allows(l Lead) bool {
+	if l.IsIP {
+		return s.IPs
+	}
+	for _, d := range s.Domains {
+		d = strings.ToLower(strings.Trim(d, "."))
+		if l.Target == d || strings.HasSuffix(l.Target, "."+d) {
+			return true
+		}
+	}
+	return false
+}
+
+// AnalyzeFunc scans a single pivot target
+type AnalyzeFunc func(ctx context.Context, target string) (*model.DomainReport, error)
+
+// Options control how far pivoting goes
+type Options struct {
+	// Depth is how many rounds of pivots follow the main scan; 0 disables pivoting
+	Depth int
+	Scope Scope
+	// MaxTargets caps the number of pivots across all rounds
+	MaxTargets int
+	// Workers is the number of pivots analyzed at once
+	Workers int
+}
+
+// Run analyzes the in-scope leads of root breadth-first, up to opts.Depth
+// rounds, and records each one in root.Pivots. Every target is analyzed at
+// most once, so names that point back at each other cannot loop. Leads the
+// enforced scope forbids are recorded in root.NotProbed instead.
+func Run(ctx context.Context, root *model.DomainReport, opts Options, analyze AnalyzeFunc, progress io.Writer) {
+	workers := opts.Workers
+	if workers < 1 {
+		workers = 1
+	}
+	visited := map[string]bool{root.Domain: true}
+
+	sources := []*model.DomainReport{root}
+	for depth := 1; depth <= opts.Depth && ctx.Err() == nil; depth++ {
+		var round []model.Pivot
+		overLimit := 0
+		for _, src := range sources {
+			for _, lead := range Leads(src) {
+				if visited[lead.Target] || !opts.Scope.Allows(lead) {
+					continue
+				}
+				if err := scope.Check(lead.Target); err != nil {
+					visited[lead.Target] = true
+					reason := err.Error()
+					var scopeErr *scope.Error
+					if errors.As(err, &scopeErr) {
+						reason = scopeErr.Reason
+					}
+					root.NotProbed = append(root.NotProbed, model.NotProbed{
+						Target: lead.Target,
+						Module: "pivot",
+						Found:  strings.TrimSpace(lead.Reason+" "+lead.Evidence) + " in " + src.Domain,
+						Reason: reason,
+					})
+					continue
+				}
+				if opts.MaxTargets > 0 && len(root.Pivots)+len(round) >= opts.MaxTargets {
+					overLimit++
+					continue
+				}
+				visited[lead.Target] = true
+				round = append(round, model.Pivot{
+					Target:   lead.Target,
+					Depth:    depth,
+					From:     src.Domain,
+					Reason:   lead.Reason,
+					Evidence: lead.Evidence,
+				})
+			}
+		}
+		if overLimit > 0 {
+			fmt.Fprintf(progress, "Pivot limit of %d reached, %d more lead(s) not followed\n", opts.MaxTargets, overLimit)
+		}
+		if len(round) == 0 {
+			break
+		}
+
+		fmt.Fprintf(progress, "\nPivoting to %d target(s) at depth %d\n", len(round), depth)
+		var (
+			wg   sync.WaitGroup
+			mu   sync.Mutex
+			jobs = make(chan int)
+		)
+		for w := 0; w < workers; w++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := range jobs {
+					p := &round[i]
+					r, err := analyze(ctx, p.Target)
+					p.Report = r
+					if err != nil {
+						p.Error = err.Error()
+					}
+					mu.Lock()
+					fmt.Fprintf(progress, "  %s (%s, from %s)\n", p.Target, strings.TrimSpace(p.Reason+" "+p.Evidence), p.From)
+					mu.Unlock()
+				}
+			}()
+		}
+		for i := range round {
+			if ctx.Err() != nil {
+				break
+			}
+			jobs <- i
+		}
+		close(jobs)
+		wg.Wait()
+
+		sources = sources[:0]
+		for _, p := range round {
+			if p.Report != nil {
+				root.Pivots = append(root.Pivots, p)
+				sources = append(sources, p.Report)
+			} else if p.Error != "" {
+				root.Pivots = append(root.Pivots, p)
+			}
+		}
+	}
+}
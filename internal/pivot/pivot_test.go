@@ -0,0 +1,48 @@
+package pivot
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/qepting91/gomain_analysis/internal/model"
+	"github.com/qepting91/gomain_analysis/internal/scope"
+)
+
+func TestRunRecordsOutOfScopeLeads(t *testing.T) {
+	s, err := scope.Parse(strings.NewReader("example.com\n!vpn.example.com\n"))
+	if err != nil {
+		t.Fatalf("scope.Parse: %v", err)
+	}
+	scope.Enforce(s)
+	defer scope.Enforce(nil)
+
+	root := model.NewDomainReport("example.com")
+	root.Target.Apex = "example.com"
+	root.CTLogs = []model.CTLog{{MinCertID: 7, NameValue: "example.com\nwww.example.com\nvpn.example.com\nexample.org"}}
+
+	var analyzed []string
+	analyze := func(ctx context.Context, target string) (*model.DomainReport, error) {
+		analyzed = append(analyzed, target)
+		return model.NewDomainReport(target), nil
+	}
+	Run(context.Background(), root, Options{Depth: 1, Scope: Scope{Domains: []string{"example.com"}}}, analyze, io.Discard)
+
+	if strings.Join(analyzed, ",") != "www.example.com" {
+		t.Errorf("analyzed %v, want only www.example.com", analyzed)
+	}
+	if len(root.NotProbed) != 1 {
+		t.Fatalf("NotProbed = %+v, want vpn.example.com", root.NotProbed)
+	}
+	np := root.NotProbed[0]
+	want := model.NotProbed{
+		Target: "vpn.example.com",
+		Module: "pivot",
+		Found:  "certificate SAN crt.sh #7 in example.com",
+		Reason: np.Reason,
+	}
+	if np != want || np.Reason == "" || strings.Contains(np.Reason, "out of scope") {
+		t.Errorf("NotProbed = %+v, want %+v with the scope rule as reason", np, want)
+	}
+}
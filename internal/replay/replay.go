@@ -0,0 +1,244 @@
+package replay
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Mode selects whether outbound lookups are recorded, replayed or left alone
+type Mode int
+
+const (
+	// Off performs lookups normally
+	Off Mode = iota
+	// Record performs lookups and saves every answer
+	Record
+	// Replay answers lookups from a previous recording without touching the network
+	Replay
+)
+
+// Kinds of recorded interactions, used as sub-directory names
+const (
+	KindHTTP  = "http"
+	KindDNS   = "dns"
+	KindWHOIS = "whois"
+	KindExec  = "exec"
+)
+
+// ErrNotRecorded is returned in replay mode for a lookup missing from the recording
+var ErrNotRecorded = errors.New("no recorded answer")
+
+// Entry is one recorded interaction as stored on disk
+type Entry struct {
+	Kind       string    `json:"kind"`
+	Key        string    `json:"key"`
+	RecordedAt time.Time `json:"recorded_at"`
+	Data       []byte    `json:"data,omitempty"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// Session records to, or replays from, a directory
+type Session struct {
+	mode Mode
+	dir  string
+	mu   sync.Mutex
+}
+
+var active atomic.Pointer[Session]
+
+// Start makes a session the process-wide recorder or replayer. Every
+// lookup that goes through Do or Transport from then on uses it.
+func Start(mode Mode, dir string) (*Session, error) {
+	switch mode {
+	case Record:
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create recording directory: %v", err)
+		}
+	case Replay:
+		info, err := os.Stat(dir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open recording: %v", err)
+		}
+		if !info.IsDir() {
+			return nil, fmt.Errorf("recording %s is not a directory", dir)
+		}
+	}
+	s := &Session{mode: mode, dir: dir}
+	active.Store(s)
+	return s, nil
+}
+
+// Active returns the running session, or nil when lookups are not intercepted
+func Active() *Session {
+	s := active.Load()
+	if s == nil || s.mode == Off {
+		return nil
+	}
+	return s
+}
+
+// Do runs fn, recording its answer under kind and key, or returns the
+// recorded answer instead of calling fn when replaying. Errors are recorded
+// and replayed too, so failed lookups reproduce as failures.
+func Do(kind, key string, fn func() ([]byte, error)) ([]byte, error) {
+	s := Active()
+	if s == nil {
+		return fn()
+	}
+	if s.mode == Replay {
+		return s.load(kind, key)
+	}
+
+	data, err := fn()
+	entry := Entry{Kind: kind, Key: key, RecordedAt: time.Now().UTC(), Data: data}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	if saveErr := s.save(entry); saveErr != nil {
+		return nil, saveErr
+	}
+	return data, err
+}
+
+// DoJSON is Do for answers that are JSON-encodable values
+func DoJSON[T any](kind, key string, fn func() (T, error)) (T, error) {
+	var v T
+	data, err := Do(kind, key, func() ([]byte, error) {
+		v, err := fn()
+		data, encErr := json.Marshal(v)
+		if encErr != nil {
+			return nil, fmt.Errorf("failed to encode %s answer: %v", kind, encErr)
+		}
+		return data, err
+	})
+	if len(data) > 0 {
+		if decErr := json.Unmarshal(data, &v); decErr != nil {
+			return v, fmt.Errorf("failed to decode recorded %s answer: %v", kind, decErr)
+		}
+	}
+	return v, err
+}
+
+// path returns where the entry for kind and key is stored
+func (s *Session) path(kind, key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(s.dir, kind, hex.EncodeToString(sum[:12])+".json")
+}
+
+func (s *Session) save(e Entry) error {
+	data, err := json.MarshalIndent(e, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode recording: %v", err)
+	}
+	p := s.path(e.Kind, e.Key)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return fmt.Errorf("failed to create recording directory: %v", err)
+	}
+	if err := os.WriteFile(p, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write recording: %v", err)
+	}
+	return nil
+}
+
+func (s *Session) load(kind, key string) ([]byte, error) {
+	data, err := os.ReadFile(s.path(kind, key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w for %s %s", ErrNotRecorded, kind, key)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read recording: %v", err)
+	}
+	var e Entry
+	if err := json.Unmarshal(data, &e); err != nil {
+		return nil, fmt.Errorf("failed to parse recording for %s %s: %v", kind, key, err)
+	}
+	if e.Error != "" {
+		return e.Data, errors.New(e.Error)
+	}
+	return e.Data, nil
+}
+
+// response is the recorded form of an HTTP response
+type response struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header"`
+	Body       []byte      `json:"body"`
+}
+
+// transport routes requests through the active session
+type transport struct {
+	base http.RoundTripper
+}
+
+// Transport wraps base so that requests are recorded or replayed whenever a
+// session is active. The session is looked up per request, so clients
+// created before Start are covered as well.
+func Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &transport{base: base}
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if Active() == nil {
+		return t.base.RoundTrip(req)
+	}
+
+	key := req.Method + " " + req.URL.String()
+	if req.Body != nil && req.Body != http.NoBody {
+		body, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		sum := sha256.Sum256(body)
+		key += " " + hex.EncodeToString(sum[:8])
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	rec, err := DoJSON(KindHTTP, key, func() (*response, error) {
+		resp, err := t.base.RoundTrip(req)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, err
+		}
+		return &response{StatusCode: resp.StatusCode, Header: resp.Header, Body: body}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if rec == nil {
+		return nil, fmt.Errorf("%w for %s", ErrNotRecorded, key)
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", rec.StatusCode, http.StatusText(rec.StatusCode)),
+		StatusCode:    rec.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        rec.Header,
+		Body:          io.NopCloser(bytes.NewReader(rec.Body)),
+		ContentLength: int64(len(rec.Body)),
+		Request:       req,
+	}, nil
+}
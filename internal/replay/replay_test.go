@@ -0,0 +1,175 @@
+package replay
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// start begins a session in dir and ends it when the test finishes
+func start(t *testing.T, mode Mode, dir string) {
+	t.Helper()
+	if _, err := Start(mode, dir); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	t.Cleanup(func() { active.Store(nil) })
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// echo answers with the method and body of the request
+var echo = roundTripFunc(func(req *http.Request) (*http.Response, error) {
+	body := ""
+	if req.Body != nil {
+		data, _ := io.ReadAll(req.Body)
+		body = string(data)
+	}
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": {"text/plain"}},
+		Body:       io.NopCloser(strings.NewReader(req.Method + " " + body)),
+	}, nil
+})
+
+// offline fails the test if a replayed request reaches the network
+func offline(t *testing.T) http.RoundTripper {
+	return roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		t.Errorf("replay sent %s %s to the network", req.Method, req.URL)
+		return nil, errors.New("network disabled")
+	})
+}
+
+func send(client *http.Client, method, url, body string) (string, error) {
+	var r io.Reader
+	if body != "" {
+		r = strings.NewReader(body)
+	}
+	req, err := http.NewRequest(method, url, r)
+	if err != nil {
+		return "", err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	return resp.Header.Get("Content-Type") + " " + string(data), err
+}
+
+func TestHTTPRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	requests := []struct {
+		method, url, body string
+	}{
+		{http.MethodGet, "https://crt.sh/?q=example.com&output=json", ""},
+		{http.MethodPost, "https://api.example.com/search", `{"q":"a"}`},
+		// Same URL, different body: a separate recording
+		{http.MethodPost, "https://api.example.com/search", `{"q":"b"}`},
+	}
+
+	start(t, Record, dir)
+	recorded := make([]string, len(requests))
+	for i, r := range requests {
+		got, err := send(&http.Client{Transport: Transport(echo)}, r.method, r.url, r.body)
+		if err != nil {
+			t.Fatalf("recording %s %s: %v", r.method, r.url, err)
+		}
+		recorded[i] = got
+	}
+	if recorded[1] == recorded[2] {
+		t.Fatalf("requests with different bodies got the same answer: %s", recorded[1])
+	}
+
+	start(t, Replay, dir)
+	client := &http.Client{Transport: Transport(offline(t))}
+	for i, r := range requests {
+		got, err := send(client, r.method, r.url, r.body)
+		if err != nil {
+			t.Errorf("replaying %s %s %s: %v", r.method, r.url, r.body, err)
+		} else if got != recorded[i] {
+			t.Errorf("replayed %q, recorded %q", got, recorded[i])
+		}
+	}
+
+	if _, err := send(client, http.MethodPost, "https://api.example.com/search", `{"q":"c"}`); !errors.Is(err, ErrNotRecorded) {
+		t.Errorf("unrecorded body: err = %v, want ErrNotRecorded", err)
+	}
+}
+
+func TestDoJSONReplaysErrors(t *testing.T) {
+	dir := t.TempDir()
+	type answer struct {
+		Records []string
+	}
+	lookupErr := errors.New("lookup _dmarc.example.com: no such host")
+
+	start(t, Record, dir)
+	if _, err := DoJSON(KindDNS, "TXT _dmarc.example.com", func() (answer, error) {
+		return answer{}, lookupErr
+	}); err != lookupErr {
+		t.Fatalf("record: err = %v, want the lookup error unchanged", err)
+	}
+	if _, err := DoJSON(KindDNS, "A example.com", func() (answer, error) {
+		return answer{Records: []string{"203.0.113.10"}}, nil
+	}); err != nil {
+		t.Fatalf("record: %v", err)
+	}
+
+	start(t, Replay, dir)
+	fail := func() (answer, error) {
+		t.Error("replay called the lookup")
+		return answer{}, nil
+	}
+	if _, err := DoJSON(KindDNS, "TXT _dmarc.example.com", fail); err == nil || err.Error() != lookupErr.Error() {
+		t.Errorf("replayed err = %v, want %q", err, lookupErr)
+	}
+	got, err := DoJSON(KindDNS, "A example.com", fail)
+	if err != nil || len(got.Records) != 1 || got.Records[0] != "203.0.113.10" {
+		t.Errorf("replayed %+v, %v", got, err)
+	}
+}
+
+func TestNotRecorded(t *testing.T) {
+	start(t, Replay, t.TempDir())
+
+	if _, err := Do(KindWHOIS, "example.com", func() ([]byte, error) {
+		t.Error("replay called the lookup")
+		return nil, nil
+	}); !errors.Is(err, ErrNotRecorded) {
+		t.Errorf("Do: err = %v, want ErrNotRecorded", err)
+	}
+	if _, err := DoJSON(KindDNS, "A example.com", func() ([]string, error) { return nil, nil }); !errors.Is(err, ErrNotRecorded) {
+		t.Errorf("DoJSON: err = %v, want ErrNotRecorded", err)
+	}
+	if _, err := send(&http.Client{Transport: Transport(offline(t))}, http.MethodGet, "https://example.com/", ""); !errors.Is(err, ErrNotRecorded) {
+		t.Errorf("HTTP: err = %v, want ErrNotRecorded", err)
+	}
+}
+
+func TestStartReplayMissingDirectory(t *testing.T) {
+	if _, err := Start(Replay, filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("Start accepted a missing recording")
+	}
+	if Active() != nil {
+		t.Error("a failed Start left a session active")
+	}
+}
+
+func TestOffPassesThrough(t *testing.T) {
+	calls := 0
+	data, err := Do(KindExec, "amass", func() ([]byte, error) {
+		calls++
+		return []byte("out"), nil
+	})
+	if err != nil || string(data) != "out" || calls != 1 {
+		t.Errorf("Do without a session = %q, %v after %d calls", data, err, calls)
+	}
+}
@@ -0,0 +1,114 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/qepting91/gomain_analysis/internal/model"
+)
+
+// ndjsonRecord is a single line of NDJSON output
+type ndjsonRecord struct {
+	Type   string      `json:"type"`
+	Domain string      `json:"domain"`
+	Data   interface{} `json:"data"`
+}
+
+// WriteJSON writes the full report as a single indented JSON document
+func WriteJSON(w io.Writer, r *model.DomainReport) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(r); err != nil {
+		return fmt.Errorf("failed to encode JSON report: %v", err)
+	}
+	return nil
+}
+
+// ReadJSON loads a report previously written in the JSON format
+func ReadJSON(path string) (*model.DomainReport, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read report %s: %v", path, err)
+	}
+	var r model.DomainReport
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("failed to parse report %s: %v", path, err)
+	}
+	return &r, nil
+}
+
+// WriteNDJSON writes the report as newline-delimited JSON, one record per line
+func WriteNDJSON(w io.Writer, r *model.DomainReport) error {
+	var records []ndjsonRecord
+	add := func(recordType string, data interface{}) {
+		records = append(records, ndjsonRecord{Type: recordType, Domain: r.Domain, Data: data})
+	}
+
+	add("scan", map[string]interface{}{
+		"target":          r.Target,
+		"generated_at":    r.GeneratedAt,
+		"interrupted":     r.Interrupted,
+		"profile":         r.Profile,
+		"skipped_modules": r.Skipped,
+	})
+	for _, m := range r.Modules {
+		add("module", m)
+	}
+	for _, f := range r.Findings {
+		add("finding", f)
+	}
+	for _, entry := range r.CTLogs {
+		add("ct_log", entry)
+	}
+	for _, cert := range r.Certificates {
+		add("certificate", cert)
+	}
+	for _, ip := range r.DNS.ARecords {
+		add("a_record", map[string]string{"ip": ip})
+	}
+	for _, mx := range r.DNS.MXRecords {
+		add("mx_record", mx)
+	}
+	if r.DNS.DMARC != nil {
+		add("dmarc", r.DNS.DMARC)
+	}
+	for _, ip := range r.DNS.ARecords {
+		if names, ok := r.DNS.ReverseDNS[ip]; ok {
+			add("reverse_dns", map[string]interface{}{"ip": ip, "names": names})
+		}
+	}
+	if r.WHOIS.Raw != "" {
+		add("whois", r.WHOIS)
+	}
+	if r.Web.Content != nil {
+		add("web", r.Web)
+	}
+	for _, file := range r.Files {
+		add("file", file)
+	}
+	for _, snapshot := range r.Wayback {
+		add("wayback", snapshot)
+	}
+	for _, result := range r.Dorks {
+		add("dork", result)
+	}
+	for _, loc := range r.Geo {
+		add("geolocation", loc)
+	}
+	for _, p := range r.Pivots {
+		add("pivot", p)
+	}
+	for _, np := range r.NotProbed {
+		add("not_probed", np)
+	}
+
+	enc := json.NewEncoder(w)
+	for _, record := range records {
+		if err := enc.Encode(record); err != nil {
+			return fmt.Errorf("failed to encode %s record: %v", record.Type, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/qepting91/gomain_analysis/internal/graph"
+	"github.com/qepting91/gomain_analysis/internal/misp"
+	"github.com/qepting91/gomain_analysis/internal/model"
+	"github.com/qepting91/gomain_analysis/internal/stix"
+)
+
+// Supported output formats
+const (
+	FormatPDF    = "pdf"
+	FormatJSON   = "json"
+	FormatNDJSON = "ndjson"
+	FormatText   = "text"
+
+	// Entity graph exports; neo4j is a zip of node and relationship CSVs
+	FormatGraphML = "graphml"
+	FormatDOT     = "dot"
+	FormatNeo4j   = "neo4j"
+
+	// FormatSTIX is a STIX 2.1 bundle for threat intelligence platforms
+	FormatSTIX = "stix"
+	// FormatMISP is a MISP event
+	FormatMISP = "misp"
+)
+
+// Formats lists every supported output format
+func Formats() []string {
+	return []string{FormatPDF, FormatJSON, FormatNDJSON, FormatText, FormatGraphML, FormatDOT, FormatNeo4j, FormatSTIX, FormatMISP}
+}
+
+// DefaultFileName is the output naming pattern used when none is configured
+const DefaultFileName = "{domain}_report.{ext}"
+
+// DefaultOutputPath returns <domain>_report.<ext> for the given format
+func DefaultOutputPath(domain, format string) string {
+	return OutputName(DefaultFileName, domain, format)
+}
+
+// OutputName fills the {domain}, {format} and {ext} placeholders of pattern
+func OutputName(pattern, domain, format string) string {
+	if pattern == "" {
+		pattern = DefaultFileName
+	}
+	ext := strings.ToLower(format)
+	switch ext {
+	case FormatText:
+		ext = "txt"
+	case FormatNeo4j:
+		ext = "zip"
+	case FormatSTIX:
+		ext = "stix.json"
+	case FormatMISP:
+		ext = "misp.json"
+	}
+	return strings.NewReplacer("{domain}", domain, "{format}", strings.ToLower(format), "{ext}", ext).Replace(pattern)
+}
+
+// ValidateFormat returns an error if format is not supported
+func ValidateFormat(format string) error {
+	for _, f := range Formats() {
+		if strings.EqualFold(f, format) {
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported output format %q (supported: %s)", format, strings.Join(Formats(), ", "))
+}
+
+// Write renders the report in the given format to w
+func Write(w io.Writer, format string, r *model.DomainReport) error {
+	switch strings.ToLower(format) {
+	case FormatPDF:
+		return WritePDF(w, r)
+	case FormatJSON:
+		return WriteJSON(w, r)
+	case FormatNDJSON:
+		return WriteNDJSON(w, r)
+	case FormatText:
+		return WriteText(w, r)
+	case FormatGraphML:
+		return graph.WriteGraphML(w, graph.Build(r))
+	case FormatDOT:
+		return graph.WriteDOT(w, graph.Build(r))
+	case FormatNeo4j:
+		return graph.WriteNeo4jCSV(w, graph.Build(r))
+	case FormatSTIX:
+		return stix.Write(w, r)
+	case FormatMISP:
+		return misp.Write(w, r)
+	default:
+		return ValidateFormat(format)
+	}
+}
+
+// WriteFile renders the report to path, or to stdout when path is "-"
+func WriteFile(path, format string, r *model.DomainReport) error {
+	if err := ValidateFormat(format); err != nil {
+		return err
+	}
+	if path == "-" {
+		return Write(os.Stdout, format, r)
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create output file %s: %v", path, err)
+	}
+	if err := Write(file, format, r); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to write output file %s: %v", path, err)
+	}
+
+	log.Printf("%s report generated successfully: %s", strings.ToUpper(format), path)
+	return nil
+}
@@ -2,58 +2,81 @@ package report
 
 import (
 	"fmt"
-	"log"
+	"io"
+	"sort"
 	"strings"
+	"time"
+
+	"github.com/qepting91/gomain_analysis/internal/geolocation"
+	"github.com/qepting91/gomain_analysis/internal/model"
 
 	"github.com/go-pdf/fpdf"
 )
 
-func GeneratePDFReport(
-	domain string,
-	links []string,
-	htmlInfo string,
-	geolocationInfo string,
-	dnsRecords []string,
-	certDetails []string,
-	reverseDNSInfo []string,
-	waybackSnapshots []string,
-	whoisInfo string,
-	dorkResults []string,
-) error {
+// WritePDF renders the report as a PDF document to w
+func WritePDF(w io.Writer, r *model.DomainReport) error {
 	pdf := fpdf.New("P", "mm", "A4", "")
-	pdf.SetTitle(fmt.Sprintf("OSINT Report for %s", domain), false)
+	pdf.SetTitle(fmt.Sprintf("OSINT Report for %s", r.Domain), false)
 	pdf.AddPage()
 	pdf.SetFont("Arial", "B", 16)
 
 	// Add title
-	title := fmt.Sprintf("OSINT Report for %s", domain)
+	title := fmt.Sprintf("OSINT Report for %s", r.Domain)
 	pdf.Cell(40, 10, title)
 	pdf.Ln(12)
 
-	// WHOIS Information
-	pdf.SetFont("Arial", "B", 12)
-	pdf.Cell(40, 10, "WHOIS Information")
+	// Scan Coverage
+	sectionHeader(pdf, "Scan Coverage")
+	if r.Target.Input != "" && r.Target.Input != r.Domain {
+		pdf.MultiCell(0, 10, fmt.Sprintf("Input: %s", r.Target.Input), "", "", false)
+	}
+	if r.Target.Apex != "" && r.Target.Apex != r.Domain {
+		pdf.MultiCell(0, 10, fmt.Sprintf("Registrable domain: %s", r.Target.Apex), "", "", false)
+	}
+	pdf.MultiCell(0, 10, fmt.Sprintf("Profile: %s", r.Profile), "", "", false)
+	if len(r.Skipped) > 0 {
+		pdf.MultiCell(0, 10, fmt.Sprintf("Not run: %s", strings.Join(r.Skipped, ", ")), "", "", false)
+	}
+	if r.Interrupted {
+		pdf.MultiCell(0, 10, "Scan was interrupted; this report is partial.", "", "", false)
+	}
+	for _, m := range r.Modules {
+		line := fmt.Sprintf("%s: %s (%s)", m.Name, m.Status, m.Duration.Round(time.Millisecond))
+		if m.Error != "" {
+			line += " - " + m.Error
+		}
+		if len(m.Evidence) > 0 {
+			line += fmt.Sprintf(", %d evidence artifact(s)", len(m.Evidence))
+		}
+		pdf.MultiCell(0, 10, line, "", "", false)
+	}
 	pdf.Ln(10)
-	pdf.SetFont("Arial", "", 10)
-	pdf.MultiCell(0, 10, whoisInfo, "", "", false)
+
+	sectionHeader(pdf, "Findings")
+	if len(r.Findings) > 0 {
+		for _, f := range r.Findings {
+			pdf.MultiCell(0, 10, formatFinding(f), "", "", false)
+		}
+	} else {
+		pdf.Cell(0, 10, "No findings.")
+	}
 	pdf.Ln(10)
 
-	// Geolocation Information
-	pdf.SetFont("Arial", "B", 12)
-	pdf.Cell(40, 10, "Geolocation Information")
+	// WHOIS Information
+	sectionHeader(pdf, "WHOIS Information")
+	pdf.MultiCell(0, 10, r.WHOIS.Raw+"\n"+formatEvidence(r.WHOIS.Evidence), "", "", false)
 	pdf.Ln(10)
-	pdf.SetFont("Arial", "", 10)
-	pdf.MultiCell(0, 10, geolocationInfo, "", "", false)
+
+	// Geolocation Information
+	sectionHeader(pdf, "Geolocation Information")
+	pdf.MultiCell(0, 10, formatGeoLocations(r.Geo), "", "", false)
 	pdf.Ln(10)
 
 	// Certificate Details
-	pdf.SetFont("Arial", "B", 12)
-	pdf.Cell(40, 10, "SSL/TLS Certificates")
-	pdf.Ln(10)
-	pdf.SetFont("Arial", "", 10)
-	if len(certDetails) > 0 {
-		for _, cert := range certDetails {
-			pdf.MultiCell(0, 10, cert, "", "", false)
+	sectionHeader(pdf, "SSL/TLS Certificates")
+	if len(r.Certificates) > 0 {
+		for _, cert := range r.Certificates {
+			pdf.MultiCell(0, 10, formatCertificate(cert), "", "", false)
 		}
 	} else {
 		pdf.Cell(0, 10, "No certificate information available.")
@@ -61,13 +84,16 @@ func GeneratePDFReport(
 	pdf.Ln(10)
 
 	// DNS Records
-	pdf.SetFont("Arial", "B", 12)
-	pdf.Cell(40, 10, "DNS Records")
-	pdf.Ln(10)
-	pdf.SetFont("Arial", "", 10)
-	if len(dnsRecords) > 0 {
-		for _, record := range dnsRecords {
-			pdf.CellFormat(0, 10, record, "", 1, "", false, 0, "")
+	sectionHeader(pdf, "DNS Records")
+	if len(r.DNS.ARecords) > 0 {
+		for _, record := range r.DNS.ARecords {
+			pdf.CellFormat(0, 10, fmt.Sprintf("DNS Record: %s", record), "", 1, "", false, 0, "")
+		}
+		for _, mx := range r.DNS.MXRecords {
+			pdf.CellFormat(0, 10, fmt.Sprintf("MX Record: %s", mx), "", 1, "", false, 0, "")
+		}
+		if r.DNS.DMARC != nil {
+			pdf.MultiCell(0, 10, formatDMARC(r.DNS.DMARC), "", "", false)
 		}
 	} else {
 		pdf.Cell(0, 10, "No DNS records found.")
@@ -75,12 +101,14 @@ func GeneratePDFReport(
 	pdf.Ln(10)
 
 	// Reverse DNS Information
-	pdf.SetFont("Arial", "B", 12)
-	pdf.Cell(40, 10, "Reverse DNS Information")
-	pdf.Ln(10)
-	pdf.SetFont("Arial", "", 10)
-	if len(reverseDNSInfo) > 0 {
-		for _, info := range reverseDNSInfo {
+	sectionHeader(pdf, "Reverse DNS Information")
+	if len(r.DNS.ReverseDNS) > 0 {
+		for _, ip := range r.DNS.ARecords {
+			domains, ok := r.DNS.ReverseDNS[ip]
+			if !ok {
+				continue
+			}
+			info := fmt.Sprintf("IP: %s\nAssociated Domains: %v", ip, domains)
 			pdf.MultiCell(0, 10, info, "", "", false)
 		}
 	} else {
@@ -89,20 +117,22 @@ func GeneratePDFReport(
 	pdf.Ln(10)
 
 	// Website Analysis
-	pdf.SetFont("Arial", "B", 12)
-	pdf.Cell(40, 10, "Website Analysis")
-	pdf.Ln(10)
-	pdf.SetFont("Arial", "", 10)
-	pdf.MultiCell(0, 10, htmlInfo, "", "", false)
+	sectionHeader(pdf, "Website Analysis")
+	pdf.MultiCell(0, 10, formatWebInfo(r.Web)+"\n"+formatEvidence(r.Web.Evidence), "", "", false)
 	pdf.Ln(10)
 
+	if len(r.Files) > 0 {
+		sectionHeader(pdf, "Common Files")
+		for _, file := range r.Files {
+			pdf.MultiCell(0, 10, formatCommonFile(file), "", "", false)
+		}
+		pdf.Ln(10)
+	}
+
 	// Links Section
-	pdf.SetFont("Arial", "B", 12)
-	pdf.Cell(40, 10, "Extracted Links")
-	pdf.Ln(10)
-	pdf.SetFont("Arial", "", 10)
-	if len(links) > 0 {
-		for _, link := range links {
+	sectionHeader(pdf, "Extracted Links")
+	if r.Web.Content != nil && len(r.Web.Content.Links) > 0 {
+		for _, link := range r.Web.Content.Links {
 			pdf.CellFormat(0, 10, link, "", 1, "", false, 0, "")
 		}
 	} else {
@@ -111,23 +141,13 @@ func GeneratePDFReport(
 	pdf.Ln(10)
 
 	// Wayback Machine Snapshots
-	pdf.SetFont("Arial", "B", 12)
-	pdf.Cell(40, 10, "Wayback Machine Snapshots")
-	pdf.Ln(10)
-	pdf.SetFont("Arial", "", 10)
-	if len(waybackSnapshots) > 0 {
-		for _, snapshot := range waybackSnapshots {
-			// Split the snapshot info to separate URL from other details
-			parts := strings.Split(snapshot, "\nURL: ")
-			pdf.MultiCell(0, 10, parts[0], "", "", false)
-			if len(parts) > 1 {
-				pdf.SetTextColor(0, 0, 255)   // Blue color for links
-				pdf.SetFont("Arial", "U", 10) // Underlined
-				pdf.AddLink()
-				pdf.WriteLinkString(10, parts[1], parts[1])
-				pdf.SetTextColor(0, 0, 0)    // Reset to black
-				pdf.SetFont("Arial", "", 10) // Reset font
-			}
+	sectionHeader(pdf, "Wayback Machine Snapshots")
+	if len(r.Wayback) > 0 {
+		for _, snapshot := range r.Wayback {
+			info := fmt.Sprintf("Timestamp: %s\nStatus: %s",
+				snapshot.Timestamp.Format("2006-01-02 15:04:05"), snapshot.Status)
+			pdf.MultiCell(0, 10, info, "", "", false)
+			writeLink(pdf, snapshot.URL)
 			pdf.Ln(5)
 		}
 	} else {
@@ -136,22 +156,14 @@ func GeneratePDFReport(
 	pdf.Ln(10)
 
 	// Google Dork Results
-	pdf.SetFont("Arial", "B", 12)
-	pdf.Cell(40, 10, "Google Dork Results")
-	pdf.Ln(10)
-	pdf.SetFont("Arial", "", 10)
-	if len(dorkResults) > 0 {
-		for _, result := range dorkResults {
-			// Split the result into query and URL
-			parts := strings.Split(result, "\nURL: ")
-			pdf.MultiCell(0, 10, parts[0], "", "", false)
-			if len(parts) > 1 {
-				pdf.SetTextColor(0, 0, 255)   // Blue color for links
-				pdf.SetFont("Arial", "U", 10) // Underlined
-				pdf.AddLink()
-				pdf.WriteLinkString(10, parts[1], parts[1])
-				pdf.SetTextColor(0, 0, 0)    // Reset to black
-				pdf.SetFont("Arial", "", 10) // Reset font
+	sectionHeader(pdf, "Google Dork Results")
+	if len(r.Dorks) > 0 {
+		for _, result := range r.Dorks {
+			if result.Error != "" {
+				pdf.MultiCell(0, 10, fmt.Sprintf("Query: %s - Error: %s", result.Query, result.Error), "", "", false)
+			} else {
+				pdf.MultiCell(0, 10, fmt.Sprintf("Query: %s", result.Query), "", "", false)
+				writeLink(pdf, result.URL)
 			}
 			pdf.Ln(5)
 		}
@@ -160,13 +172,205 @@ func GeneratePDFReport(
 	}
 	pdf.Ln(10)
 
-	// Save PDF to file
-	outputFile := fmt.Sprintf("%s_report.pdf", domain)
-	err := pdf.OutputFileAndClose(outputFile)
-	if err != nil {
-		return fmt.Errorf("failed to generate PDF report: %v", err)
+	if len(r.Pivots) > 0 {
+		sectionHeader(pdf, "Pivots")
+		for _, p := range r.Pivots {
+			pdf.MultiCell(0, 10, formatPivot(p), "", "", false)
+		}
+		pdf.Ln(10)
+	}
+
+	if len(r.NotProbed) > 0 {
+		sectionHeader(pdf, "Discovered but Not Probed")
+		for _, np := range r.NotProbed {
+			pdf.MultiCell(0, 10, formatNotProbed(np), "", "", false)
+		}
+		pdf.Ln(10)
 	}
 
-	log.Printf("PDF report generated successfully: %s", outputFile)
+	if err := pdf.Output(w); err != nil {
+		return fmt.Errorf("failed to generate PDF report: %v", err)
+	}
 	return nil
 }
+
+// sectionHeader writes a bold section title and switches back to body text
+func sectionHeader(pdf *fpdf.Fpdf, title string) {
+	pdf.SetFont("Arial", "B", 12)
+	pdf.Cell(40, 10, title)
+	pdf.Ln(10)
+	pdf.SetFont("Arial", "", 10)
+}
+
+// writeLink writes a clickable URL on its own line
+func writeLink(pdf *fpdf.Fpdf, url string) {
+	if url == "" {
+		return
+	}
+	pdf.SetTextColor(0, 0, 255)   // Blue color for links
+	pdf.SetFont("Arial", "U", 10) // Underlined
+	pdf.WriteLinkString(10, url, url)
+	pdf.SetTextColor(0, 0, 0)    // Reset to black
+	pdf.SetFont("Arial", "", 10) // Reset font
+	pdf.Ln(10)
+}
+
+func formatCertificate(cert model.Certificate) string {
+	return fmt.Sprintf(`
+Certificate Details:
+ID: %d
+Subject: %s
+Issuer: %s
+Valid From: %s
+Valid To: %s
+DNS Names: %v
+%s`,
+		cert.ID, cert.Subject, cert.Issuer, cert.NotBefore, cert.NotAfter, cert.DNSNames,
+		formatEvidence(cert.Evidence))
+}
+
+// formatFinding shows a finding with what triggered it and how to fix it
+func formatFinding(f model.Finding) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "[%s] %s: %s\n", strings.ToUpper(f.Severity), f.Title, f.Subject)
+	fmt.Fprintf(&b, "  Evidence: %s\n", f.Evidence)
+	if len(f.Artifacts) > 0 {
+		fmt.Fprintf(&b, "  Artifacts: %s\n", strings.Join(f.Artifacts, ", "))
+	}
+	fmt.Fprintf(&b, "  Remediation: %s\n", f.Remediation)
+	return b.String()
+}
+
+// formatCommonFile names a common file the site served and its size
+func formatCommonFile(file model.CommonFile) string {
+	return fmt.Sprintf("%s (%d bytes): %s", file.Path, file.Size, file.URL)
+}
+
+// formatDMARC shows the DMARC policy and where it was published
+func formatDMARC(d *model.DMARCInfo) string {
+	if d.Record == "" {
+		return "DMARC: none published"
+	}
+	return fmt.Sprintf("DMARC (_dmarc.%s): %s", d.Domain, d.Record)
+}
+
+// formatEvidence names the evidence artifacts a finding was derived from
+func formatEvidence(ids []string) string {
+	if len(ids) == 0 {
+		return ""
+	}
+	return "Evidence: " + strings.Join(ids, ", ") + "\n"
+}
+
+func formatGeoLocations(locations []model.GeoLocation) string {
+	var result strings.Builder
+	for _, loc := range locations {
+		fmt.Fprintf(&result, `
+IP: %s
+Location Information:
+%s
+`, loc.IP, geolocation.FormatGeoLocation(loc))
+	}
+	return result.String()
+}
+
+func formatWebInfo(web model.WebInfo) string {
+	content := web.Content
+	if content == nil {
+		return "No website content available."
+	}
+	return fmt.Sprintf(`
+Website Analysis
+---------------
+Title: %s
+
+Contact Information:
+• Emails: %v
+• Phone Numbers: %v
+
+Links Analysis:
+• Internal Links Count: %d
+• External Links Count: %d
+
+Social Media Presence:
+%s
+
+Technical Details:
+• Technologies: %v
+• Forms: %v
+• Scripts: %v
+• Stylesheets: %v
+
+Additional Information:
+• Comments: %v
+`,
+		content.Title,
+		strings.Join(content.Emails, ", "),
+		strings.Join(content.PhoneNumbers, ", "),
+		len(content.InternalLinks),
+		len(content.ExternalLinks),
+		formatSocialMedia(content.SocialMedia),
+		strings.Join(content.Technologies, ", "),
+		strings.Join(content.Forms, ", "),
+		strings.Join(content.Scripts, "\n  "),
+		strings.Join(content.StyleSheets, "\n  "),
+		strings.Join(content.Comments, "\n  "),
+	)
+}
+
+// formatNotProbed says what was left alone, by which module and why
+func formatNotProbed(np model.NotProbed) string {
+	s := fmt.Sprintf("%s (%s): %s", np.Target, np.Module, np.Reason)
+	if np.Found != "" {
+		s += ", found as " + np.Found
+	}
+	return s
+}
+
+// formatPivot summarizes a pivot: why it was followed and what it found
+func formatPivot(p model.Pivot) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s (depth %d): %s", p.Target, p.Depth, p.Reason)
+	if p.Evidence != "" {
+		fmt.Fprintf(&b, " %s", p.Evidence)
+	}
+	fmt.Fprintf(&b, ", found in %s\n", p.From)
+	if p.Error != "" {
+		fmt.Fprintf(&b, "  Error: %s\n", p.Error)
+	}
+	if r := p.Report; r != nil {
+		if len(r.DNS.ARecords) > 0 {
+			fmt.Fprintf(&b, "  Addresses: %s\n", strings.Join(r.DNS.ARecords, ", "))
+		}
+		for _, loc := range r.Geo {
+			fmt.Fprintf(&b, "  Location of %s: %s, %s\n", loc.IP, loc.City, loc.Country)
+		}
+		if r.Web.Content != nil {
+			fmt.Fprintf(&b, "  Website: %s", r.Web.URL)
+			if r.Web.Content.Title != "" {
+				fmt.Fprintf(&b, " (%s)", r.Web.Content.Title)
+			}
+			fmt.Fprintln(&b)
+		}
+		for _, m := range r.Modules {
+			if m.Status != model.StatusCompleted {
+				fmt.Fprintf(&b, "  %s: %s\n", m.Name, m.Status)
+			}
+		}
+	}
+	return b.String()
+}
+
+// formatSocialMedia formats social media links
+func formatSocialMedia(socialMedia map[string][]string) string {
+	platforms := make([]string, 0, len(socialMedia))
+	for platform := range socialMedia {
+		platforms = append(platforms, platform)
+	}
+	sort.Strings(platforms)
+	var result strings.Builder
+	for _, platform := range platforms {
+		fmt.Fprintf(&result, "• %s: %s\n", platform, strings.Join(socialMedia[platform], ", "))
+	}
+	return result.String()
+}
@@ -0,0 +1,99 @@
+package report_test
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/qepting91/gomain_analysis/internal/model"
+	"github.com/qepting91/gomain_analysis/internal/pipeline"
+	"github.com/qepting91/gomain_analysis/internal/replay"
+	"github.com/qepting91/gomain_analysis/internal/report"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// replayScan runs the web module against the capture in testdata/recording,
+// without network access, and pins everything that depends on the clock
+func replayScan(t *testing.T) *model.DomainReport {
+	t.Helper()
+	if _, err := replay.Start(replay.Replay, filepath.Join("testdata", "recording")); err != nil {
+		t.Fatalf("replay.Start: %v", err)
+	}
+	t.Cleanup(func() { replay.Start(replay.Off, "") })
+
+	sel, err := pipeline.Select(pipeline.DefaultSteps(nil), "", []string{"web"}, nil)
+	if err != nil {
+		t.Fatalf("Select: %v", err)
+	}
+	r, err := pipeline.Analyze(context.Background(), "shop.example.com", sel, nil)
+	if err != nil {
+		t.Fatalf("Analyze: %v", err)
+	}
+	if !r.ModuleCompleted("web") {
+		t.Fatalf("web module did not complete: %+v", r.Modules)
+	}
+	r.GeneratedAt = time.Date(2024, 1, 16, 9, 30, 0, 0, time.UTC)
+	for i := range r.Modules {
+		r.Modules[i].Started = time.Time{}
+		r.Modules[i].Duration = 0
+	}
+	return r
+}
+
+func TestReplayedPageParsing(t *testing.T) {
+	content := replayScan(t).Web.Content
+	if content == nil {
+		t.Fatal("no parsed content")
+	}
+	tests := []struct {
+		field string
+		got   string
+		want  string
+	}{
+		{"title", content.Title, "Example Shop | Handmade Goods"},
+		{"generator", content.MetaTags["generator"], "WordPress 6.4.2"},
+		{"emails", strings.Join(content.Emails, ","), "orders@example.com"},
+		{"phone numbers", strings.Join(content.PhoneNumbers, ","), "+1-555-0100"},
+		{"internal links", strings.Join(content.InternalLinks, ","), "/,/catalog/,/about/"},
+		{"social media", strings.Join(content.SocialMedia["GitHub"], ","), "https://github.com/exampleshop/storefront"},
+		{"technologies", strings.Join(content.Technologies, ","), "Bootstrap,WordPress,jQuery"},
+		{"forms", strings.Join(content.Forms, ","), "http://shop.example.com/newsletter/subscribe,/search"},
+		{"comments", strings.Join(content.Comments, "|"), "build 2024-01-15 deploy@web02|TODO remove before launch: staging admin password is Summer2024"},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s = %q, want %q", tt.field, tt.got, tt.want)
+		}
+	}
+}
+
+func TestReplayedReportOutput(t *testing.T) {
+	r := replayScan(t)
+	for _, format := range []string{report.FormatText, report.FormatJSON} {
+		t.Run(format, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := report.Write(&buf, format, r); err != nil {
+				t.Fatalf("Write: %v", err)
+			}
+			golden := filepath.Join("testdata", "shop.example.com."+format)
+			if *update {
+				if err := os.WriteFile(golden, buf.Bytes(), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("reading golden file (run with -update to create it): %v", err)
+			}
+			if !bytes.Equal(buf.Bytes(), want) {
+				t.Errorf("%s output differs from %s:\n%s", format, golden, buf.String())
+			}
+		})
+	}
+}
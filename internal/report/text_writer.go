@@ -0,0 +1,145 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/qepting91/gomain_analysis/internal/model"
+)
+
+// WriteText renders the report as plain text with the same sections as the PDF
+func WriteText(w io.Writer, r *model.DomainReport) error {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "OSINT Report for %s\n", r.Domain)
+	fmt.Fprintf(&b, "Generated: %s\n", r.GeneratedAt.Format(time.RFC3339))
+
+	textSection(&b, "Scan Coverage")
+	if r.Target.Input != "" && r.Target.Input != r.Domain {
+		fmt.Fprintf(&b, "Input: %s\n", r.Target.Input)
+	}
+	if r.Target.Apex != "" && r.Target.Apex != r.Domain {
+		fmt.Fprintf(&b, "Registrable domain: %s\n", r.Target.Apex)
+	}
+	fmt.Fprintf(&b, "Profile: %s\n", r.Profile)
+	if r.Interrupted {
+		fmt.Fprintln(&b, "Scan was interrupted; this report is partial.")
+	}
+	if len(r.Skipped) > 0 {
+		fmt.Fprintf(&b, "Not run: %s\n", strings.Join(r.Skipped, ", "))
+	}
+	for _, m := range r.Modules {
+		fmt.Fprintf(&b, "%s: %s (%s)", m.Name, m.Status, m.Duration.Round(time.Millisecond))
+		if m.Error != "" {
+			fmt.Fprintf(&b, " - %s", m.Error)
+		}
+		if len(m.Evidence) > 0 {
+			fmt.Fprintf(&b, ", %d evidence artifact(s)", len(m.Evidence))
+		}
+		fmt.Fprintln(&b)
+	}
+
+	textSection(&b, "Findings")
+	if len(r.Findings) == 0 {
+		fmt.Fprintln(&b, "No findings.")
+	}
+	for _, f := range r.Findings {
+		fmt.Fprint(&b, formatFinding(f))
+	}
+
+	textSection(&b, "WHOIS Information")
+	fmt.Fprintln(&b, r.WHOIS.Raw)
+	fmt.Fprint(&b, formatEvidence(r.WHOIS.Evidence))
+
+	textSection(&b, "Geolocation Information")
+	fmt.Fprint(&b, formatGeoLocations(r.Geo))
+
+	textSection(&b, "SSL/TLS Certificates")
+	if len(r.Certificates) == 0 {
+		fmt.Fprintln(&b, "No certificate information available.")
+	}
+	for _, cert := range r.Certificates {
+		fmt.Fprint(&b, formatCertificate(cert))
+	}
+
+	textSection(&b, "DNS Records")
+	if len(r.DNS.ARecords) == 0 {
+		fmt.Fprintln(&b, "No DNS records found.")
+	}
+	for _, record := range r.DNS.ARecords {
+		fmt.Fprintf(&b, "DNS Record: %s\n", record)
+	}
+	for _, mx := range r.DNS.MXRecords {
+		fmt.Fprintf(&b, "MX Record: %s\n", mx)
+	}
+	if r.DNS.DMARC != nil {
+		fmt.Fprintln(&b, formatDMARC(r.DNS.DMARC))
+	}
+
+	textSection(&b, "Reverse DNS Information")
+	if len(r.DNS.ReverseDNS) == 0 {
+		fmt.Fprintln(&b, "No reverse DNS information found.")
+	}
+	for _, ip := range r.DNS.ARecords {
+		if domains, ok := r.DNS.ReverseDNS[ip]; ok {
+			fmt.Fprintf(&b, "IP: %s\nAssociated Domains: %v\n", ip, domains)
+		}
+	}
+
+	textSection(&b, "Website Analysis")
+	fmt.Fprintln(&b, formatWebInfo(r.Web))
+	fmt.Fprint(&b, formatEvidence(r.Web.Evidence))
+
+	if len(r.Files) > 0 {
+		textSection(&b, "Common Files")
+		for _, file := range r.Files {
+			fmt.Fprintln(&b, formatCommonFile(file))
+		}
+	}
+
+	textSection(&b, "Wayback Machine Snapshots")
+	if len(r.Wayback) == 0 {
+		fmt.Fprintln(&b, "No Wayback Machine snapshots found.")
+	}
+	for _, snapshot := range r.Wayback {
+		fmt.Fprintf(&b, "Timestamp: %s\nStatus: %s\nURL: %s\n",
+			snapshot.Timestamp.Format("2006-01-02 15:04:05"), snapshot.Status, snapshot.URL)
+	}
+
+	textSection(&b, "Google Dork Results")
+	if len(r.Dorks) == 0 {
+		fmt.Fprintln(&b, "No Google dork results found.")
+	}
+	for _, result := range r.Dorks {
+		if result.Error != "" {
+			fmt.Fprintf(&b, "Query: %s - Error: %s\n", result.Query, result.Error)
+		} else {
+			fmt.Fprintf(&b, "Query: %s\nURL: %s\n", result.Query, result.URL)
+		}
+	}
+
+	if len(r.Pivots) > 0 {
+		textSection(&b, "Pivots")
+		for _, p := range r.Pivots {
+			fmt.Fprint(&b, formatPivot(p))
+		}
+	}
+
+	if len(r.NotProbed) > 0 {
+		textSection(&b, "Discovered but Not Probed")
+		for _, np := range r.NotProbed {
+			fmt.Fprintln(&b, formatNotProbed(np))
+		}
+	}
+
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return fmt.Errorf("failed to write text report: %v", err)
+	}
+	return nil
+}
+
+func textSection(b *strings.Builder, title string) {
+	fmt.Fprintf(b, "\n%s\n%s\n", title, strings.Repeat("-", len(title)))
+}
@@ -0,0 +1,249 @@
+package scope
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/qepting91/gomain_analysis/internal/target"
+)
+
+// ErrOutOfScope is matched by every error Check returns
+var ErrOutOfScope = errors.New("out of scope")
+
+// Error explains why a host may not be probed
+type Error struct {
+	Host   string
+	Reason string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s is out of scope: %s", e.Host, e.Reason)
+}
+
+// Is makes errors.Is(err, ErrOutOfScope) true
+func (e *Error) Is(err error) bool {
+	return err == ErrOutOfScope
+}
+
+// Scope holds the rules of engagement: the domains and networks that may be
+// probed, and exclusions that win over both. A domain covers its subdomains.
+// Once any domain or network is listed, everything else is out of scope: an
+// address must be in a listed network and a name under a listed domain, or
+// resolve only to listed networks. A scope of exclusions alone allows
+// everything that is not excluded.
+type Scope struct {
+	Domains         []string
+	Networks        []*net.IPNet
+	ExcludeDomains  []string
+	ExcludeNetworks []*net.IPNet
+
+	// Resolve, when set, returns the addresses of a name so names can be
+	// checked against the networks. Without it names only match domains.
+	Resolve func(host string) ([]string, error)
+
+	mu       sync.Mutex
+	resolved map[string][]string
+}
+
+// Parse reads a scope file. Each line holds a domain, an IP address or a
+// CIDR; a leading ! turns it into an exclusion. Blank lines and text after #
+// are ignored.
+//
+//	example.com
+//	*.example.org
+//	203.0.113.0/24
+//	!vpn.example.com
+func Parse(r io.Reader) (*Scope, error) {
+	s := &Scope{}
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		exclude := strings.HasPrefix(line, "!")
+		rule := strings.TrimSpace(strings.TrimPrefix(line, "!"))
+
+		if network, err := parseNetwork(rule); err == nil {
+			if exclude {
+				s.ExcludeNetworks = append(s.ExcludeNetworks, network)
+			} else {
+				s.Networks = append(s.Networks, network)
+			}
+			continue
+		}
+		domain, err := target.Normalize(strings.TrimPrefix(rule, "*."))
+		if err != nil || strings.ContainsAny(rule, "/:") {
+			return nil, fmt.Errorf("invalid scope rule %q on line %d", line, n)
+		}
+		if exclude {
+			s.ExcludeDomains = append(s.ExcludeDomains, domain)
+		} else {
+			s.Domains = append(s.Domains, domain)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read scope: %v", err)
+	}
+	return s, nil
+}
+
+// Load reads the scope file at path
+func Load(path string) (*Scope, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open scope file: %v", err)
+	}
+	defer f.Close()
+	s, err := Parse(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse scope file %s: %v", path, err)
+	}
+	return s, nil
+}
+
+// Check returns an *Error if host, a name or an IP address, may not be
+// probed. A nil scope allows everything.
+func (s *Scope) Check(host string) error {
+	if s == nil {
+		return nil
+	}
+	host = normalize(host)
+	restricted := len(s.Domains) > 0 || len(s.Networks) > 0
+	if ip := net.ParseIP(host); ip != nil {
+		if network := findNetwork(s.ExcludeNetworks, ip); network != nil {
+			return &Error{Host: host, Reason: "excluded by " + network.String()}
+		}
+		if !restricted || findNetwork(s.Networks, ip) != nil {
+			return nil
+		}
+		return &Error{Host: host, Reason: "not in any scope network"}
+	}
+
+	for _, domain := range s.ExcludeDomains {
+		if matchDomain(host, domain) {
+			return &Error{Host: host, Reason: "excluded by " + domain}
+		}
+	}
+	var addrs []string
+	if len(s.Networks) > 0 || len(s.ExcludeNetworks) > 0 {
+		addrs = s.lookup(host)
+	}
+	for _, addr := range addrs {
+		if network := findNetwork(s.ExcludeNetworks, net.ParseIP(addr)); network != nil {
+			return &Error{Host: host, Reason: fmt.Sprintf("resolves to %s, excluded by %s", addr, network)}
+		}
+	}
+	if !restricted {
+		return nil
+	}
+	for _, domain := range s.Domains {
+		if matchDomain(host, domain) {
+			return nil
+		}
+	}
+	if len(addrs) > 0 && len(s.Networks) > 0 {
+		for _, addr := range addrs {
+			if findNetwork(s.Networks, net.ParseIP(addr)) == nil {
+				return &Error{Host: host, Reason: fmt.Sprintf("not under any scope domain and resolves to %s, outside every scope network", addr)}
+			}
+		}
+		return nil
+	}
+	return &Error{Host: host, Reason: "not under any scope domain"}
+}
+
+// lookup resolves host with Resolve, once per name. Failures are treated as
+// having no addresses, so the name can still match a domain.
+func (s *Scope) lookup(host string) []string {
+	if s.Resolve == nil {
+		return nil
+	}
+	s.mu.Lock()
+	addrs, ok := s.resolved[host]
+	s.mu.Unlock()
+	if ok {
+		return addrs
+	}
+	addrs, err := s.Resolve(host)
+	if err != nil {
+		log.Printf("Error resolving %s for the scope check: %v", host, err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.resolved == nil {
+		s.resolved = make(map[string][]string)
+	}
+	s.resolved[host] = addrs
+	return addrs
+}
+
+// Allows reports whether host may be probed
+func (s *Scope) Allows(host string) bool {
+	return s.Check(host) == nil
+}
+
+var active atomic.Pointer[Scope]
+
+// Enforce makes s the scope every probe is checked against; nil lifts it
+func Enforce(s *Scope) {
+	active.Store(s)
+}
+
+// Active returns the enforced scope, or nil when there is none
+func Active() *Scope {
+	return active.Load()
+}
+
+// Check tests host against the enforced scope
+func Check(host string) error {
+	return Active().Check(host)
+}
+
+// parseNetwork accepts a CIDR or a single address
+func parseNetwork(rule string) (*net.IPNet, error) {
+	if ip := net.ParseIP(rule); ip != nil {
+		bits := 128
+		if ip4 := ip.To4(); ip4 != nil {
+			ip, bits = ip4, 32
+		}
+		return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+	}
+	_, network, err := net.ParseCIDR(rule)
+	return network, err
+}
+
+// findNetwork returns the first network containing ip, or nil
+func findNetwork(networks []*net.IPNet, ip net.IP) *net.IPNet {
+	if ip == nil {
+		return nil
+	}
+	for _, network := range networks {
+		if network.Contains(ip) {
+			return network
+		}
+	}
+	return nil
+}
+
+func normalize(host string) string {
+	host = strings.ToLower(strings.TrimSpace(host))
+	host = strings.TrimSuffix(strings.TrimSuffix(strings.TrimPrefix(host, "["), "]"), ".")
+	return host
+}
+
+func matchDomain(host, domain string) bool {
+	return host == domain || strings.HasSuffix(host, "."+domain)
+}
@@ -0,0 +1,142 @@
+package scope
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func mustParse(t *testing.T, text string) *Scope {
+	t.Helper()
+	s, err := Parse(strings.NewReader(text))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	return s
+}
+
+func TestParse(t *testing.T) {
+	s := mustParse(t, `
+# engagement
+Example.COM.          # trailing comment
+*.example.org
+203.0.113.0/24
+198.51.100.7
+2001:db8::/32
+!vpn.example.com
+!203.0.113.7
+`)
+	if got := strings.Join(s.Domains, ","); got != "example.com,example.org" {
+		t.Errorf("Domains = %s", got)
+	}
+	if got := strings.Join(s.ExcludeDomains, ","); got != "vpn.example.com" {
+		t.Errorf("ExcludeDomains = %s", got)
+	}
+	var networks []string
+	for _, n := range s.Networks {
+		networks = append(networks, n.String())
+	}
+	if got := strings.Join(networks, ","); got != "203.0.113.0/24,198.51.100.7/32,2001:db8::/32" {
+		t.Errorf("Networks = %s", got)
+	}
+	if len(s.ExcludeNetworks) != 1 || s.ExcludeNetworks[0].String() != "203.0.113.7/32" {
+		t.Errorf("ExcludeNetworks = %v", s.ExcludeNetworks)
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, rule := range []string{"https://example.com", "example.com:443", "203.0.113.0/33", "not a domain"} {
+		if _, err := Parse(strings.NewReader("example.com\n" + rule)); err == nil {
+			t.Errorf("Parse accepted %q", rule)
+		} else if !strings.Contains(err.Error(), "line 2") {
+			t.Errorf("error for %q does not name the line: %v", rule, err)
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	resolve := func(host string) ([]string, error) {
+		switch host {
+		case "cdn.partner.net":
+			return []string{"203.0.113.20"}, nil
+		case "split.partner.net":
+			return []string{"203.0.113.21", "192.0.2.1"}, nil
+		case "vpn-alias.partner.net":
+			return []string{"203.0.113.7"}, nil
+		}
+		return nil, fmt.Errorf("no such host %s", host)
+	}
+
+	tests := []struct {
+		name    string
+		scope   string
+		resolve bool
+		allowed []string
+		denied  []string
+	}{
+		{
+			name:    "domains only",
+			scope:   "example.com",
+			allowed: []string{"example.com", "www.example.com", "WWW.Example.com."},
+			denied:  []string{"example.org", "notexample.com", "203.0.113.10", "2001:db8::1"},
+		},
+		{
+			name:    "wildcard",
+			scope:   "*.example.com",
+			allowed: []string{"example.com", "a.b.example.com"},
+			denied:  []string{"example.net"},
+		},
+		{
+			name:    "networks only",
+			scope:   "203.0.113.0/24\n2001:db8::/32",
+			allowed: []string{"203.0.113.10", "[2001:db8::1]"},
+			denied:  []string{"198.51.100.1", "example.com", "www.example.com"},
+		},
+		{
+			name:    "networks only with resolution",
+			scope:   "203.0.113.0/24\n!203.0.113.7",
+			resolve: true,
+			allowed: []string{"cdn.partner.net"},
+			denied:  []string{"split.partner.net", "vpn-alias.partner.net", "unknown.partner.net", "203.0.113.7"},
+		},
+		{
+			name:    "exclusions win",
+			scope:   "example.com\n203.0.113.0/24\n!vpn.example.com\n!203.0.113.7",
+			allowed: []string{"www.example.com", "203.0.113.8"},
+			denied:  []string{"vpn.example.com", "a.vpn.example.com", "203.0.113.7"},
+		},
+		{
+			name:    "exclusions only",
+			scope:   "!vpn.example.com\n!203.0.113.7",
+			allowed: []string{"www.example.com", "example.org", "198.51.100.1"},
+			denied:  []string{"vpn.example.com", "203.0.113.7"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := mustParse(t, tt.scope)
+			if tt.resolve {
+				s.Resolve = resolve
+			}
+			for _, host := range tt.allowed {
+				if err := s.Check(host); err != nil {
+					t.Errorf("Check(%q) = %v, want allowed", host, err)
+				}
+			}
+			for _, host := range tt.denied {
+				err := s.Check(host)
+				if !errors.Is(err, ErrOutOfScope) {
+					t.Errorf("Check(%q) = %v, want out of scope", host, err)
+				}
+			}
+		})
+	}
+}
+
+func TestNilScopeAllowsEverything(t *testing.T) {
+	var s *Scope
+	if err := s.Check("anything.example"); err != nil {
+		t.Errorf("nil scope: %v", err)
+	}
+}
@@ -0,0 +1,375 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/qepting91/gomain_analysis/internal/model"
+	"github.com/qepting91/gomain_analysis/internal/pipeline"
+	"github.com/qepting91/gomain_analysis/internal/report"
+	"github.com/qepting91/gomain_analysis/internal/store"
+	"github.com/qepting91/gomain_analysis/internal/target"
+)
+
+// Job states reported by the API
+const (
+	JobQueued    = "queued"
+	JobRunning   = "running"
+	JobCompleted = "completed"
+	JobFailed    = "failed"
+	JobCancelled = "cancelled"
+)
+
+// ScanRequest is the body of POST /api/scans
+type ScanRequest struct {
+	Domain  string   `json:"domain"`
+	Profile string   `json:"profile,omitempty"`
+	Modules []string `json:"modules,omitempty"`
+	Skip    []string `json:"skip,omitempty"`
+}
+
+// Job is a submitted scan and its progress
+type Job struct {
+	ID          string     `json:"id"`
+	Domain      string     `json:"domain"`
+	Profile     string     `json:"profile"`
+	Modules     []string   `json:"modules"`
+	Status      string     `json:"status"`
+	Error       string     `json:"error,omitempty"`
+	Interrupted bool       `json:"interrupted,omitempty"`
+	ScanID      uint64     `json:"scan_id,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	StartedAt   *time.Time `json:"started_at,omitempty"`
+	FinishedAt  *time.Time `json:"finished_at,omitempty"`
+
+	input     string
+	selection *pipeline.Selection
+	report    *model.DomainReport
+	cancel    context.CancelFunc
+}
+
+// Server runs scan jobs submitted over a REST API
+type Server struct {
+	// Steps are shared by every job so HTTP clients and resolvers are reused
+	Steps []pipeline.Step
+	// OpenStore, when set, opens the history that receives every finished
+	// report. It is opened per job, so the CLI can use the history while the
+	// server runs.
+	OpenStore func() (*store.Store, error)
+	// Timeout bounds each job; zero means no limit
+	Timeout time.Duration
+	// Profile is used for requests that name neither a profile nor modules
+	Profile string
+	// JobTTL is how long a finished job stays available from the API. Its
+	// report remains in the Store.
+	JobTTL time.Duration
+
+	ctx  context.Context
+	sem  chan struct{}
+	wg   sync.WaitGroup
+	mu   sync.Mutex
+	jobs map[string]*Job
+}
+
+// DefaultJobTTL is the JobTTL set by New
+const DefaultJobTTL = time.Hour
+
+// New returns a Server that runs at most workers jobs at a time. Jobs are
+// cancelled when ctx is.
+func New(ctx context.Context, steps []pipeline.Step, workers int) *Server {
+	if workers < 1 {
+		workers = 1
+	}
+	return &Server{
+		Steps:  steps,
+		JobTTL: DefaultJobTTL,
+		ctx:    ctx,
+		sem:    make(chan struct{}, workers),
+		jobs:   make(map[string]*Job),
+	}
+}
+
+// Wait blocks until every job has finished, including saving its report, or
+// until ctx is done. Call it before exiting.
+func (s *Server) Wait(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("scan jobs still running: %v", ctx.Err())
+	}
+}
+
+// Handler returns the HTTP routes of the API
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/scans", s.handleSubmit)
+	mux.HandleFunc("GET /api/scans", s.handleList)
+	mux.HandleFunc("GET /api/scans/{id}", s.handleStatus)
+	mux.HandleFunc("DELETE /api/scans/{id}", s.handleCancel)
+	mux.HandleFunc("GET /api/scans/{id}/result", s.handleResult)
+	mux.HandleFunc("GET /api/scans/{id}/report.pdf", s.handlePDF)
+	mux.HandleFunc("GET /api/modules", s.handleModules)
+	return mux
+}
+
+func (s *Server) handleSubmit(w http.ResponseWriter, r *http.Request) {
+	var req ScanRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid request body: %v", err))
+		return
+	}
+	if strings.TrimSpace(req.Domain) == "" {
+		writeError(w, http.StatusBadRequest, "domain is required")
+		return
+	}
+	t, err := target.Parse(req.Domain)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if req.Profile == "" && len(req.Modules) == 0 {
+		req.Profile = s.Profile
+	}
+	sel, err := pipeline.Select(s.Steps, req.Profile, req.Modules, req.Skip)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	job := &Job{
+		ID:        newJobID(),
+		Domain:    t.Host,
+		input:     req.Domain,
+		Profile:   sel.Profile,
+		Modules:   pipeline.StepNames(sel.Steps),
+		Status:    JobQueued,
+		CreatedAt: time.Now().UTC(),
+		selection: sel,
+	}
+	ctx, cancel := context.WithCancel(s.ctx)
+	job.cancel = cancel
+
+	s.mu.Lock()
+	s.evictLocked(time.Now())
+	s.jobs[job.ID] = job
+	view := *job
+	s.wg.Add(1)
+	s.mu.Unlock()
+
+	go s.run(ctx, job)
+
+	w.Header().Set("Location", "/api/scans/"+job.ID)
+	writeJSON(w, http.StatusAccepted, view)
+}
+
+func (s *Server) run(ctx context.Context, job *Job) {
+	defer s.wg.Done()
+	defer job.cancel()
+
+	select {
+	case s.sem <- struct{}{}:
+		defer func() { <-s.sem }()
+	case <-ctx.Done():
+		s.finish(job, nil, ctx.Err())
+		return
+	}
+
+	s.mu.Lock()
+	started := time.Now().UTC()
+	job.Status = JobRunning
+	job.StartedAt = &started
+	s.mu.Unlock()
+
+	if s.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.Timeout)
+		defer cancel()
+	}
+	r, err := pipeline.Analyze(ctx, job.input, job.selection, nil)
+	s.finish(job, r, err)
+}
+
+func (s *Server) finish(job *Job, r *model.DomainReport, err error) {
+	var scanID uint64
+	if r != nil && s.OpenStore != nil {
+		var saveErr error
+		if scanID, saveErr = s.save(r); saveErr != nil {
+			log.Printf("Error saving scan of %s: %v", job.Domain, saveErr)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	finished := time.Now().UTC()
+	job.FinishedAt = &finished
+	job.report = r
+	job.ScanID = scanID
+	switch {
+	case err != nil && errors.Is(err, context.Canceled):
+		job.Status = JobCancelled
+		job.Error = err.Error()
+	case err != nil:
+		job.Status = JobFailed
+		job.Error = err.Error()
+	case r.Interrupted:
+		// Cancelled part way; keep the partial report available
+		job.Status = JobCancelled
+		job.Interrupted = true
+	default:
+		job.Status = JobCompleted
+	}
+}
+
+func (s *Server) save(r *model.DomainReport) (uint64, error) {
+	st, err := s.OpenStore()
+	if err != nil {
+		return 0, err
+	}
+	defer st.Close()
+	return st.Save(r)
+}
+
+// evictLocked forgets jobs that finished more than JobTTL before now. The
+// caller must hold s.mu.
+func (s *Server) evictLocked(now time.Time) {
+	if s.JobTTL <= 0 {
+		return
+	}
+	for id, job := range s.jobs {
+		if job.FinishedAt != nil && now.Sub(*job.FinishedAt) > s.JobTTL {
+			delete(s.jobs, id)
+		}
+	}
+}
+
+func (s *Server) handleList(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.evictLocked(time.Now())
+	jobs := make([]Job, 0, len(s.jobs))
+	for _, job := range s.jobs {
+		jobs = append(jobs, *job)
+	}
+	s.mu.Unlock()
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].CreatedAt.Before(jobs[j].CreatedAt) })
+	writeJSON(w, http.StatusOK, jobs)
+}
+
+func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	job, ok := s.lookup(r.PathValue("id"))
+	if !ok {
+		writeError(w, http.StatusNotFound, "scan not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, job)
+}
+
+func (s *Server) handleCancel(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	job, ok := s.jobs[r.PathValue("id")]
+	if ok {
+		job.cancel()
+	}
+	s.mu.Unlock()
+	if !ok {
+		writeError(w, http.StatusNotFound, "scan not found")
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (s *Server) handleResult(w http.ResponseWriter, r *http.Request) {
+	rep, ok := s.result(w, r.PathValue("id"))
+	if !ok {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := report.WriteJSON(w, rep); err != nil {
+		log.Printf("Error writing JSON result: %v", err)
+	}
+}
+
+func (s *Server) handlePDF(w http.ResponseWriter, r *http.Request) {
+	rep, ok := s.result(w, r.PathValue("id"))
+	if !ok {
+		return
+	}
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", report.DefaultOutputPath(rep.Domain, report.FormatPDF)))
+	if err := report.WritePDF(w, rep); err != nil {
+		log.Printf("Error writing PDF report: %v", err)
+	}
+}
+
+func (s *Server) handleModules(w http.ResponseWriter, r *http.Request) {
+	profiles := make(map[string][]string)
+	for _, name := range pipeline.Profiles() {
+		profiles[name], _ = pipeline.ProfileModules(name)
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"modules":  pipeline.StepNames(s.Steps),
+		"profiles": profiles,
+	})
+}
+
+// result returns the report of a finished job, writing an error response if
+// the job is unknown or still running
+func (s *Server) result(w http.ResponseWriter, id string) (*model.DomainReport, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, ok := s.jobs[id]
+	if !ok {
+		writeError(w, http.StatusNotFound, "scan not found")
+		return nil, false
+	}
+	if job.report == nil {
+		writeError(w, http.StatusConflict, fmt.Sprintf("scan is %s, no result available", job.Status))
+		return nil, false
+	}
+	return job.report, true
+}
+
+func (s *Server) lookup(id string) (Job, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, ok := s.jobs[id]
+	if !ok {
+		return Job{}, false
+	}
+	return *job, true
+}
+
+func newJobID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("Error writing response: %v", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
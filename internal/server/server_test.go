@@ -0,0 +1,232 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/qepting91/gomain_analysis/internal/model"
+	"github.com/qepting91/gomain_analysis/internal/pipeline"
+	"github.com/qepting91/gomain_analysis/internal/store"
+)
+
+// newTestServer returns a server whose only module waits until release is
+// closed or the job is cancelled
+func newTestServer(t *testing.T) (*Server, *httptest.Server, chan struct{}) {
+	t.Helper()
+	release := make(chan struct{})
+	steps := []pipeline.Step{{
+		Name: "dns",
+		Run: func(ctx context.Context, r *model.DomainReport) error {
+			select {
+			case <-release:
+				r.DNS.ARecords = []string{"203.0.113.10"}
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		},
+	}}
+	ctx, cancel := context.WithCancel(context.Background())
+	srv := New(ctx, steps, 1)
+	ts := httptest.NewServer(srv.Handler())
+	t.Cleanup(func() {
+		ts.Close()
+		cancel()
+		srv.Wait(context.Background())
+	})
+	return srv, ts, release
+}
+
+func request(t *testing.T, method, url, body string) (*http.Response, []byte) {
+	t.Helper()
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, url, err)
+	}
+	defer resp.Body.Close()
+	var buf bytes.Buffer
+	buf.ReadFrom(resp.Body)
+	return resp, buf.Bytes()
+}
+
+func submit(t *testing.T, ts *httptest.Server, body string) Job {
+	t.Helper()
+	resp, data := request(t, http.MethodPost, ts.URL+"/api/scans", body)
+	if resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("submit: status %d: %s", resp.StatusCode, data)
+	}
+	var job Job
+	if err := json.Unmarshal(data, &job); err != nil {
+		t.Fatalf("decoding job: %v", err)
+	}
+	if got := resp.Header.Get("Location"); got != "/api/scans/"+job.ID {
+		t.Errorf("Location = %q", got)
+	}
+	return job
+}
+
+// waitFor polls the job until it has the given status
+func waitFor(t *testing.T, ts *httptest.Server, id, status string) Job {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		resp, data := request(t, http.MethodGet, ts.URL+"/api/scans/"+id, "")
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("status: %d: %s", resp.StatusCode, data)
+		}
+		var job Job
+		if err := json.Unmarshal(data, &job); err != nil {
+			t.Fatal(err)
+		}
+		if job.Status == status {
+			return job
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("job %s is %s, want %s", id, job.Status, status)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestSubmitAndResult(t *testing.T) {
+	srv, ts, release := newTestServer(t)
+	db := filepath.Join(t.TempDir(), "history.db")
+	srv.OpenStore = func() (*store.Store, error) { return store.Open(db) }
+
+	job := submit(t, ts, `{"domain": "https://Example.com/login", "modules": ["dns"]}`)
+	if job.Domain != "example.com" || job.Status != JobQueued || job.Profile != pipeline.ProfileCustom {
+		t.Errorf("submitted job = %+v", job)
+	}
+	waitFor(t, ts, job.ID, JobRunning)
+
+	// No result until the job is done
+	for _, path := range []string{"/result", "/report.pdf"} {
+		if resp, data := request(t, http.MethodGet, ts.URL+"/api/scans/"+job.ID+path, ""); resp.StatusCode != http.StatusConflict {
+			t.Errorf("%s while running: status %d: %s", path, resp.StatusCode, data)
+		}
+	}
+
+	close(release)
+	done := waitFor(t, ts, job.ID, JobCompleted)
+	if done.FinishedAt == nil || done.ScanID == 0 {
+		t.Errorf("completed job = %+v, want a finish time and a stored scan ID", done)
+	}
+
+	resp, data := request(t, http.MethodGet, ts.URL+"/api/scans/"+job.ID+"/result", "")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("result: status %d: %s", resp.StatusCode, data)
+	}
+	var r model.DomainReport
+	if err := json.Unmarshal(data, &r); err != nil {
+		t.Fatalf("decoding result: %v", err)
+	}
+	if r.Domain != "example.com" || len(r.DNS.ARecords) != 1 {
+		t.Errorf("result = %s", data)
+	}
+
+	resp, data = request(t, http.MethodGet, ts.URL+"/api/scans/"+job.ID+"/report.pdf", "")
+	if resp.StatusCode != http.StatusOK || !bytes.HasPrefix(data, []byte("%PDF")) {
+		t.Errorf("report.pdf: status %d, %d bytes", resp.StatusCode, len(data))
+	}
+}
+
+func TestCancel(t *testing.T) {
+	_, ts, _ := newTestServer(t)
+	job := submit(t, ts, `{"domain": "example.com", "modules": ["dns"]}`)
+	waitFor(t, ts, job.ID, JobRunning)
+
+	if resp, data := request(t, http.MethodDelete, ts.URL+"/api/scans/"+job.ID, ""); resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("cancel: status %d: %s", resp.StatusCode, data)
+	}
+	cancelled := waitFor(t, ts, job.ID, JobCancelled)
+	if !cancelled.Interrupted {
+		t.Errorf("cancelled job = %+v, want the partial report kept", cancelled)
+	}
+	// The partial report stays available
+	if resp, data := request(t, http.MethodGet, ts.URL+"/api/scans/"+job.ID+"/result", ""); resp.StatusCode != http.StatusOK {
+		t.Errorf("result of cancelled job: status %d: %s", resp.StatusCode, data)
+	}
+}
+
+func TestBadRequests(t *testing.T) {
+	_, ts, _ := newTestServer(t)
+	tests := []struct {
+		name, method, path, body string
+		want                     int
+	}{
+		{"invalid JSON", http.MethodPost, "/api/scans", `{"domain":`, http.StatusBadRequest},
+		{"missing domain", http.MethodPost, "/api/scans", `{}`, http.StatusBadRequest},
+		{"invalid domain", http.MethodPost, "/api/scans", `{"domain": "localhost"}`, http.StatusBadRequest},
+		{"unknown module", http.MethodPost, "/api/scans", `{"domain": "example.com", "modules": ["nmap"]}`, http.StatusBadRequest},
+		{"unknown job", http.MethodGet, "/api/scans/missing", "", http.StatusNotFound},
+		{"cancel unknown job", http.MethodDelete, "/api/scans/missing", "", http.StatusNotFound},
+		{"result of unknown job", http.MethodGet, "/api/scans/missing/result", "", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, data := request(t, tt.method, ts.URL+tt.path, tt.body)
+			if resp.StatusCode != tt.want {
+				t.Errorf("status %d, want %d: %s", resp.StatusCode, tt.want, data)
+			}
+			var e map[string]string
+			if err := json.Unmarshal(data, &e); err != nil || e["error"] == "" {
+				t.Errorf("body %s is not a JSON error", data)
+			}
+		})
+	}
+}
+
+func TestFinishedJobsExpire(t *testing.T) {
+	srv, ts, release := newTestServer(t)
+	srv.JobTTL = 50 * time.Millisecond
+	close(release)
+
+	job := submit(t, ts, `{"domain": "example.com", "modules": ["dns"]}`)
+	waitFor(t, ts, job.ID, JobCompleted)
+
+	list := func() []Job {
+		_, data := request(t, http.MethodGet, ts.URL+"/api/scans", "")
+		var jobs []Job
+		if err := json.Unmarshal(data, &jobs); err != nil {
+			t.Fatalf("decoding list: %v", err)
+		}
+		return jobs
+	}
+	if jobs := list(); len(jobs) != 1 {
+		t.Fatalf("list right after completion = %+v", jobs)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	if jobs := list(); len(jobs) != 0 {
+		t.Errorf("expired job still listed: %+v", jobs)
+	}
+	if resp, _ := request(t, http.MethodGet, ts.URL+"/api/scans/"+job.ID, ""); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expired job status: %d, want 404", resp.StatusCode)
+	}
+}
+
+func TestWaitForJobs(t *testing.T) {
+	srv, ts, release := newTestServer(t)
+	submit(t, ts, `{"domain": "example.com", "modules": ["dns"]}`)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := srv.Wait(ctx); err == nil {
+		t.Error("Wait returned while a job was running")
+	}
+	close(release)
+	if err := srv.Wait(context.Background()); err != nil {
+		t.Errorf("Wait: %v", err)
+	}
+}
@@ -0,0 +1,364 @@
+package settings
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/qepting91/gomain_analysis/internal/cache"
+	"github.com/qepting91/gomain_analysis/internal/dns"
+	"github.com/qepting91/gomain_analysis/internal/fetcher"
+	"github.com/qepting91/gomain_analysis/internal/report"
+	"github.com/qepting91/gomain_analysis/internal/store"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FileName is the name of the configuration file looked up in the working
+// directory and in the per-user data directory
+const FileName = "gomain_analysis.yaml"
+
+// EnvConfig names the environment variable holding an explicit config path
+const EnvConfig = "GOMAIN_CONFIG"
+
+// Environment variables that override individual settings
+const (
+	EnvGeoLiteDB   = "GOMAIN_GEOLITE_DB"
+	EnvQueriesFile = "GOMAIN_QUERIES_FILE"
+	EnvHistoryDB   = "GOMAIN_HISTORY_DB"
+	EnvOutputDir   = "GOMAIN_OUTPUT_DIR"
+	EnvProfile     = "GOMAIN_PROFILE"
+	EnvDNSResolver = "GOMAIN_DNS_RESOLVER"
+	EnvDNSThreads  = "GOMAIN_DNS_THREADS"
+	EnvProxy       = "GOMAIN_PROXY"
+	EnvUserAgent   = "GOMAIN_USER_AGENT"
+	EnvCacheDir    = "GOMAIN_CACHE_DIR"
+	EnvMISPURL     = "GOMAIN_MISP_URL"
+	EnvMISPKey     = "GOMAIN_MISP_KEY"
+	EnvScopeFile   = "GOMAIN_SCOPE_FILE"
+	EnvAuditLog    = "GOMAIN_AUDIT_LOG"
+)
+
+// Duration is a time.Duration written as "30s" or "5m" in YAML
+type Duration time.Duration
+
+// UnmarshalYAML accepts Go duration strings
+func (d *Duration) UnmarshalYAML(node *yaml.Node) error {
+	v, err := time.ParseDuration(node.Value)
+	if err != nil {
+		return fmt.Errorf("invalid duration %q on line %d", node.Value, node.Line)
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// MarshalYAML writes the duration in its short string form
+func (d Duration) MarshalYAML() (interface{}, error) {
+	return time.Duration(d).String(), nil
+}
+
+// Config holds every path and tunable the tool reads at runtime
+type Config struct {
+	GeoLiteDB   string              `yaml:"geolite_db"`
+	QueriesFile string              `yaml:"queries_file"`
+	HistoryDB   string              `yaml:"history_db"`
+	ScopeFile   string              `yaml:"scope_file"`
+	AuditLog    string              `yaml:"audit_log"`
+	Profile     string              `yaml:"profile"`
+	Output      OutputConfig        `yaml:"output"`
+	DNS         DNSConfig           `yaml:"dns"`
+	Web         WebConfig           `yaml:"web"`
+	Network     NetworkConfig       `yaml:"network"`
+	Cache       CacheConfig         `yaml:"cache"`
+	MISP        MISPConfig          `yaml:"misp"`
+	Timeouts    map[string]Duration `yaml:"timeouts,omitempty"`
+
+	// Source is the file the configuration was read from, if any
+	Source string `yaml:"-"`
+}
+
+// OutputConfig controls where analyze writes reports
+type OutputConfig struct {
+	Dir string `yaml:"dir"`
+	// Filename is a pattern where {domain} and {ext} are substituted
+	Filename string `yaml:"filename"`
+}
+
+// DNSConfig mirrors the tunables of dns.DNSResolver
+type DNSConfig struct {
+	Threads     int    `yaml:"threads"`
+	Resolver    string `yaml:"resolver"`
+	UseDefaults bool   `yaml:"use_defaults"`
+}
+
+// WebConfig lists the paths probed by the web fetcher
+type WebConfig struct {
+	CommonFiles []string `yaml:"common_files"`
+}
+
+// NetworkConfig controls how outbound connections are made
+type NetworkConfig struct {
+	// Proxy is an http, https, socks5 or socks5h URL, or "tor"
+	Proxy         string `yaml:"proxy"`
+	UserAgent     string `yaml:"user_agent"`
+	Insecure      bool   `yaml:"insecure_skip_verify"`
+	MinTLSVersion string `yaml:"min_tls_version"`
+	CACert        string `yaml:"ca_cert"`
+
+	// RateLimit is the default requests per second per host, 0 for unlimited
+	RateLimit      float64            `yaml:"rate_limit"`
+	HostRateLimits map[string]float64 `yaml:"host_rate_limits"`
+	MaxRetries     int                `yaml:"max_retries"`
+	RetryBackoff   Duration           `yaml:"retry_backoff"`
+	MaxRetryWait   Duration           `yaml:"max_retry_wait"`
+}
+
+// CacheConfig controls the on-disk cache of third-party answers
+type CacheConfig struct {
+	Dir string `yaml:"dir"`
+	// TTL is keyed by source: pem, crtsh, search, whois and wayback.
+	// A TTL of 0 disables caching for that source.
+	TTL map[string]Duration `yaml:"ttl"`
+}
+
+// MISPConfig is the MISP instance that --push-misp sends events to
+type MISPConfig struct {
+	URL string `yaml:"url"`
+	// Key is the automation key; prefer $GOMAIN_MISP_KEY over the file
+	Key string `yaml:"key"`
+}
+
+// TTLs returns the cache TTLs as plain durations
+func (c CacheConfig) TTLs() map[string]time.Duration {
+	ttls := make(map[string]time.Duration, len(c.TTL))
+	for source, ttl := range c.TTL {
+		ttls[source] = time.Duration(ttl)
+	}
+	return ttls
+}
+
+// DataDir returns ~/.gomain_analysis, where per-user state is kept
+func DataDir() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "."
+	}
+	return filepath.Join(home, ".gomain_analysis")
+}
+
+// Default returns the built-in configuration
+func Default() *Config {
+	resolver := dns.NewDNSResolver()
+	ttls := make(map[string]Duration)
+	for source, ttl := range cache.DefaultTTLs() {
+		ttls[source] = Duration(ttl)
+	}
+	return &Config{
+		GeoLiteDB:   findFile(filepath.Join("assets", "GeoLite2-City.mmdb")),
+		QueriesFile: findFile(filepath.Join("queries", "queries.txt")),
+		HistoryDB:   store.DefaultPath(),
+		Profile:     "standard",
+		Output: OutputConfig{
+			Dir:      ".",
+			Filename: report.DefaultFileName,
+		},
+		DNS: DNSConfig{
+			Threads:     resolver.Threads,
+			Resolver:    resolver.Resolver,
+			UseDefaults: resolver.UseDefaults,
+		},
+		Web: WebConfig{
+			CommonFiles: append([]string(nil), fetcher.DefaultCommonPaths...),
+		},
+		Network: NetworkConfig{
+			// The public services we query throttle aggressively
+			HostRateLimits: map[string]float64{
+				"crt.sh":         2,
+				"www.google.com": 0.5,
+				"archive.org":    1,
+			},
+			MaxRetries:   3,
+			RetryBackoff: Duration(time.Second),
+			MaxRetryWait: Duration(time.Minute),
+		},
+		Cache: CacheConfig{
+			Dir: filepath.Join(DataDir(), "cache"),
+			TTL: ttls,
+		},
+	}
+}
+
+// Load reads the configuration file at path on top of the defaults and then
+// applies environment overrides. An empty path searches $GOMAIN_CONFIG, the
+// working directory and DataDir in that order; finding no file is not an error.
+func Load(path string) (*Config, error) {
+	cfg := Default()
+
+	explicit := path != ""
+	if !explicit {
+		path = os.Getenv(EnvConfig)
+		explicit = path != ""
+	}
+	if !explicit {
+		path = searchConfig()
+	}
+
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			if explicit || !errors.Is(err, os.ErrNotExist) {
+				return nil, fmt.Errorf("failed to read config file: %v", err)
+			}
+		} else {
+			if err := cfg.decode(data, filepath.Dir(path)); err != nil {
+				return nil, fmt.Errorf("failed to parse config file %s: %v", path, err)
+			}
+			cfg.Source = path
+		}
+	}
+
+	if err := cfg.applyEnv(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// decode merges YAML data into c, resolving relative paths against dir
+func (c *Config) decode(data []byte, dir string) error {
+	// Decoding into c keeps the defaults for keys absent from the file
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+
+	// Paths written in the file are relative to the file, not to the
+	// working directory the binary happens to run from
+	var file Config
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return err
+	}
+	for _, p := range []struct{ set, dst *string }{
+		{&file.GeoLiteDB, &c.GeoLiteDB},
+		{&file.QueriesFile, &c.QueriesFile},
+		{&file.HistoryDB, &c.HistoryDB},
+		{&file.ScopeFile, &c.ScopeFile},
+		{&file.AuditLog, &c.AuditLog},
+		{&file.Output.Dir, &c.Output.Dir},
+		{&file.Network.CACert, &c.Network.CACert},
+		{&file.Cache.Dir, &c.Cache.Dir},
+	} {
+		if *p.set != "" {
+			*p.dst = resolvePath(*p.set, dir)
+		}
+	}
+	return nil
+}
+
+// applyEnv overrides settings from GOMAIN_* environment variables
+func (c *Config) applyEnv() error {
+	for env, p := range map[string]*string{
+		EnvGeoLiteDB:   &c.GeoLiteDB,
+		EnvQueriesFile: &c.QueriesFile,
+		EnvHistoryDB:   &c.HistoryDB,
+		EnvScopeFile:   &c.ScopeFile,
+		EnvAuditLog:    &c.AuditLog,
+		EnvOutputDir:   &c.Output.Dir,
+		EnvProfile:     &c.Profile,
+		EnvDNSResolver: &c.DNS.Resolver,
+		EnvProxy:       &c.Network.Proxy,
+		EnvUserAgent:   &c.Network.UserAgent,
+		EnvCacheDir:    &c.Cache.Dir,
+		EnvMISPURL:     &c.MISP.URL,
+		EnvMISPKey:     &c.MISP.Key,
+	} {
+		if v, ok := os.LookupEnv(env); ok {
+			*p = expandHome(v)
+		}
+	}
+	if v, ok := os.LookupEnv(EnvDNSThreads); ok {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return fmt.Errorf("invalid %s %q", EnvDNSThreads, v)
+		}
+		c.DNS.Threads = n
+	}
+	return nil
+}
+
+// Timeout returns the configured deadline for a module, or def if none is set
+func (c *Config) Timeout(module string, def time.Duration) time.Duration {
+	if d, ok := c.Timeouts[module]; ok && d > 0 {
+		return time.Duration(d)
+	}
+	return def
+}
+
+// Write encodes the configuration as YAML
+func (c *Config) Write(w io.Writer) error {
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(c); err != nil {
+		return fmt.Errorf("failed to encode configuration: %v", err)
+	}
+	return enc.Close()
+}
+
+// searchConfig returns the first config file found in the working directory
+// or the data directory
+func searchConfig() string {
+	for _, p := range []string{FileName, filepath.Join(DataDir(), "config.yaml")} {
+		if _, err := os.Stat(p); err == nil {
+			return p
+		}
+	}
+	return ""
+}
+
+// findFile locates a bundled asset given relative to the project root. The
+// working directory wins, then the directory holding the executable, then
+// DataDir; if none has it the relative path is returned unchanged.
+func findFile(rel string) string {
+	dirs := []string{"."}
+	if exe, err := os.Executable(); err == nil {
+		dirs = append(dirs, filepath.Dir(exe))
+	}
+	dirs = append(dirs, DataDir())
+
+	for _, dir := range dirs {
+		p := filepath.Join(dir, rel)
+		if _, err := os.Stat(p); err == nil {
+			if dir == "." {
+				return rel
+			}
+			return p
+		}
+	}
+	return rel
+}
+
+// resolvePath expands ~ and makes a relative path relative to dir
+func resolvePath(p, dir string) string {
+	p = expandHome(p)
+	if p == "" || filepath.IsAbs(p) {
+		return p
+	}
+	return filepath.Join(dir, p)
+}
+
+func expandHome(p string) string {
+	if p != "~" && !strings.HasPrefix(p, "~/") {
+		return p
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return p
+	}
+	return filepath.Join(home, p[1:])
+}
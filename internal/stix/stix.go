@@ -0,0 +1,318 @@
+package stix
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/qepting91/gomain_analysis/internal/model"
+)
+
+// scoNamespace is the UUIDv5 namespace the STIX 2.1 specification defines
+// for deterministic cyber-observable identifiers
+var scoNamespace = [16]byte{0x00, 0xab, 0xed, 0xb4, 0xaa, 0x42, 0x46, 0x6c, 0x9c, 0x01, 0xfe, 0xd2, 0x33, 0x15, 0xa9, 0xb7}
+
+// timestampFormat is the STIX timestamp with millisecond precision
+const timestampFormat = "2006-01-02T15:04:05.000Z"
+
+// Object is a single STIX object; properties are kept in a map because each
+// object type has its own set
+type Object map[string]interface{}
+
+// Bundle is a STIX 2.1 bundle
+type Bundle struct {
+	Type    string   `json:"type"`
+	ID      string   `json:"id"`
+	Objects []Object `json:"objects"`
+}
+
+// builder collects the objects of one bundle without duplicates
+type builder struct {
+	created string
+	scan    string
+	objects []Object
+	byID    map[string]Object
+	scos    []string
+	rels    map[string]bool
+}
+
+// Build maps a report to a STIX bundle. Observables get the deterministic
+// IDs the specification prescribes and the remaining objects are derived
+// from the domain and scan time, so exporting the same scan twice yields the
+// same bundle and ingesting it again updates rather than duplicates.
+func Build(r *model.DomainReport) *Bundle {
+	b := &builder{
+		created: r.GeneratedAt.UTC().Format(timestampFormat),
+		scan:    r.Domain + "|" + r.GeneratedAt.UTC().Format(time.RFC3339Nano),
+		byID:    make(map[string]Object),
+		rels:    make(map[string]bool),
+	}
+
+	tool := b.sdo("identity", "gomain_analysis", Object{
+		"name":           "gomain_analysis",
+		"identity_class": "system",
+	})
+
+	var root string
+	if r.Target.IsIP {
+		root = b.ip(r.Domain)
+	} else {
+		root = b.domain(r.Domain)
+	}
+	apex := root
+	if r.Target.Apex != "" && r.Target.Apex != r.Domain {
+		apex = b.domain(r.Target.Apex)
+		b.relate(apex, "has-subdomain", root)
+	}
+	for _, name := range r.Subdomains() {
+		b.relate(apex, "has-subdomain", b.domain(name))
+	}
+
+	for _, ip := range r.DNS.ARecords {
+		b.relate(root, "resolves-to", b.ip(ip))
+	}
+	for _, mx := range r.DNS.MXRecords {
+		b.relate(root, "has-mx", b.domain(mx.Host))
+	}
+	reverse := make([]string, 0, len(r.DNS.ReverseDNS))
+	for ip := range r.DNS.ReverseDNS {
+		reverse = append(reverse, ip)
+	}
+	sort.Strings(reverse)
+	for _, ip := range reverse {
+		for _, name := range r.DNS.ReverseDNS[ip] {
+			b.relate(b.ip(ip), "resolves-to", b.domain(name))
+		}
+	}
+
+	for _, cert := range r.Certificates {
+		id := b.certificate(cert)
+		if id == "" {
+			continue
+		}
+		for _, name := range cert.DNSNames {
+			if !strings.HasPrefix(name, "*") {
+				b.relate(id, "covers", b.domain(name))
+			}
+		}
+	}
+
+	if r.WHOIS.Registrar != "" {
+		registrar := b.sdo("identity", "registrar|"+r.WHOIS.Registrar, Object{
+			"name":           r.WHOIS.Registrar,
+			"identity_class": "organization",
+		})
+		b.relate(apex, "registered-with", registrar)
+	}
+	for _, ns := range r.WHOIS.NameServers {
+		b.relate(apex, "uses-name-server", b.domain(ns))
+	}
+
+	if content := r.Web.Content; content != nil {
+		page := b.sco("url", Object{"value": r.Web.URL})
+		b.relate(root, "hosts", page)
+		platforms := make([]string, 0, len(content.SocialMedia))
+		for platform := range content.SocialMedia {
+			platforms = append(platforms, platform)
+		}
+		sort.Strings(platforms)
+		for _, platform := range platforms {
+			for _, link := range content.SocialMedia[platform] {
+				b.relate(page, "links-to", b.sco("url", Object{"value": link}))
+			}
+		}
+		for _, email := range content.Emails {
+			b.relate(page, "mentions", b.sco("email-addr", Object{"value": strings.ToLower(email)}))
+		}
+	}
+
+	// Findings are analyst notes on the target; the remediation is part of
+	// the content since notes have no field of their own for it
+	for _, f := range r.Findings {
+		b.sdo("note", b.scan+"|finding|"+f.Rule+"|"+f.Subject, Object{
+			"created_by_ref": tool,
+			"abstract":       fmt.Sprintf("[%s] %s: %s", strings.ToUpper(f.Severity), f.Title, f.Subject),
+			"content":        f.Evidence + "\n\nRemediation: " + f.Remediation,
+			"labels":         []string{f.Severity, f.Rule},
+			"object_refs":    []string{root},
+		})
+	}
+
+	b.sdo("observed-data", b.scan, Object{
+		"created_by_ref":  tool,
+		"first_observed":  b.created,
+		"last_observed":   b.created,
+		"number_observed": 1,
+		"object_refs":     b.scos,
+	})
+
+	// The grouping is the scan itself: everything above, as one unit
+	refs := make([]string, 0, len(b.objects))
+	for _, obj := range b.objects {
+		refs = append(refs, obj["id"].(string))
+	}
+	b.sdo("grouping", b.scan, Object{
+		"created_by_ref": tool,
+		"name":           "OSINT scan of " + r.Domain,
+		"description":    "Collected by gomain_analysis with the " + r.Profile + " profile",
+		"context":        "unspecified",
+		"object_refs":    refs,
+	})
+
+	return &Bundle{Type: "bundle", ID: "bundle--" + uuid5(b.scan), Objects: b.objects}
+}
+
+// Write renders the report as an indented STIX 2.1 bundle
+func Write(w io.Writer, r *model.DomainReport) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(Build(r)); err != nil {
+		return fmt.Errorf("failed to encode STIX bundle: %v", err)
+	}
+	return nil
+}
+
+func (b *builder) add(obj Object) string {
+	id := obj["id"].(string)
+	if _, ok := b.byID[id]; !ok {
+		b.byID[id] = obj
+		b.objects = append(b.objects, obj)
+	}
+	return id
+}
+
+// sco adds a cyber-observable whose ID is derived from the given properties,
+// which must be exactly the type's ID contributing properties
+func (b *builder) sco(typ string, contributing Object) string {
+	id := scoID(typ, contributing)
+	if _, ok := b.byID[id]; ok {
+		return id
+	}
+	obj := Object{"type": typ, "spec_version": "2.1", "id": id}
+	for k, v := range contributing {
+		obj[k] = v
+	}
+	b.scos = append(b.scos, id)
+	return b.add(obj)
+}
+
+// scoID returns the deterministic ID of an observable
+func scoID(typ string, contributing Object) string {
+	return typ + "--" + uuid5(canonical(contributing))
+}
+
+func (b *builder) domain(name string) string {
+	name = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(name), "."))
+	return b.sco("domain-name", Object{"value": name})
+}
+
+func (b *builder) ip(addr string) string {
+	if strings.Contains(addr, ":") {
+		return b.sco("ipv6-addr", Object{"value": addr})
+	}
+	return b.sco("ipv4-addr", Object{"value": addr})
+}
+
+// certificate adds an x509-certificate. Its ID is derived from the SHA-256
+// fingerprint and serial number, or from whichever of the two is known, and
+// the remaining fields are added afterwards. Entries with neither, such as
+// bare CT log rows, are skipped and "" is returned.
+func (b *builder) certificate(cert model.Certificate) string {
+	contributing := Object{}
+	if cert.FingerprintSHA256 != "" {
+		contributing["hashes"] = map[string]string{"SHA-256": strings.ToUpper(cert.FingerprintSHA256)}
+	}
+	if cert.SerialNumber != "" {
+		contributing["serial_number"] = cert.SerialNumber
+	}
+	if len(contributing) == 0 {
+		return ""
+	}
+	if id := scoID("x509-certificate", contributing); b.byID[id] != nil {
+		return id
+	}
+	id := b.sco("x509-certificate", contributing)
+	obj := b.byID[id]
+	if cert.FingerprintSHA1 != "" {
+		hashes, _ := obj["hashes"].(map[string]string)
+		if hashes == nil {
+			hashes = make(map[string]string)
+			obj["hashes"] = hashes
+		}
+		hashes["SHA-1"] = strings.ToUpper(cert.FingerprintSHA1)
+	}
+	obj["issuer"] = cert.Issuer
+	obj["subject"] = cert.Subject
+	if !cert.NotBefore.IsZero() {
+		obj["validity_not_before"] = cert.NotBefore.UTC().Format(timestampFormat)
+	}
+	if !cert.NotAfter.IsZero() {
+		obj["validity_not_after"] = cert.NotAfter.UTC().Format(timestampFormat)
+	}
+	if len(cert.DNSNames) > 0 {
+		sans := make([]string, len(cert.DNSNames))
+		for i, name := range cert.DNSNames {
+			sans[i] = "DNS:" + name
+		}
+		obj["x509_v3_extensions"] = Object{"subject_alternative_name": strings.Join(sans, ", ")}
+	}
+	return id
+}
+
+// sdo adds a domain object whose ID is derived from name. Identities use
+// their own name so they stay the same across scans; everything else
+// includes the scan.
+func (b *builder) sdo(typ, name string, props Object) string {
+	obj := Object{
+		"type":         typ,
+		"spec_version": "2.1",
+		"id":           typ + "--" + uuid5(name),
+		"created":      b.created,
+		"modified":     b.created,
+	}
+	for k, v := range props {
+		obj[k] = v
+	}
+	return b.add(obj)
+}
+
+func (b *builder) relate(source, relType, target string) {
+	key := source + "|" + relType + "|" + target
+	if source == target || b.rels[key] {
+		return
+	}
+	b.rels[key] = true
+	b.sdo("relationship", b.scan+"|"+key, Object{
+		"relationship_type": relType,
+		"source_ref":        source,
+		"target_ref":        target,
+	})
+}
+
+// canonical serializes v with sorted keys, no whitespace and no HTML
+// escaping, as the specification requires for deterministic IDs
+func canonical(v interface{}) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.Encode(v)
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// uuid5 returns the name-based UUID of name in the STIX namespace
+func uuid5(name string) string {
+	h := sha1.New()
+	h.Write(scoNamespace[:])
+	h.Write([]byte(name))
+	u := h.Sum(nil)[:16]
+	u[6] = (u[6] & 0x0f) | 0x50
+	u[8] = (u[8] & 0x3f) | 0x80
+	s := hex.EncodeToString(u)
+	return s[0:8] + "-" + s[8:12] + "-" + s[12:16] + "-" + s[16:20] + "-" + s[20:]
+}
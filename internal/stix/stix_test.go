@@ -0,0 +1,66 @@
+package stix
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/qepting91/gomain_analysis/internal/model"
+)
+
+func testReport() *model.DomainReport {
+	r := model.NewDomainReport("www.example.com")
+	r.GeneratedAt = time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	r.Target = model.Target{Host: "www.example.com", Apex: "example.com"}
+	r.DNS.ARecords = []string{"203.0.113.10", "2001:db8::1"}
+	r.Certificates = []model.Certificate{
+		{ID: 1, SerialNumber: "01", FingerprintSHA256: "aa", DNSNames: []string{"www.example.com"}},
+		{ID: 2, SerialNumber: "02"},
+		{ID: 3, SerialNumber: "03"},
+		{ID: 4},
+	}
+	return r
+}
+
+func objectsOfType(b *Bundle, typ string) []Object {
+	var objs []Object
+	for _, obj := range b.Objects {
+		if obj["type"] == typ {
+			objs = append(objs, obj)
+		}
+	}
+	return objs
+}
+
+func TestBuildIsDeterministic(t *testing.T) {
+	first, second := Build(testReport()), Build(testReport())
+	if first.ID != second.ID {
+		t.Errorf("bundle IDs differ: %s and %s", first.ID, second.ID)
+	}
+	if !reflect.DeepEqual(first.Objects, second.Objects) {
+		t.Error("the same report produced different objects")
+	}
+}
+
+func TestObservableID(t *testing.T) {
+	// Example from the STIX 2.1 specification
+	want := "domain-name--bedb4899-d24b-5401-bc86-8f6b4cc18ec7"
+	if got := scoID("domain-name", Object{"value": "example.com"}); got != want {
+		t.Errorf("scoID = %s, want %s", got, want)
+	}
+}
+
+func TestCertificatesWithoutFingerprint(t *testing.T) {
+	certs := objectsOfType(Build(testReport()), "x509-certificate")
+	if len(certs) != 3 {
+		t.Fatalf("got %d certificates, want 3 (one per serial number)", len(certs))
+	}
+	seen := make(map[string]bool)
+	for _, cert := range certs {
+		id := cert["id"].(string)
+		if seen[id] {
+			t.Errorf("certificates share ID %s", id)
+		}
+		seen[id] = true
+	}
+}
@@ -0,0 +1,210 @@
+package store
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/qepting91/gomain_analysis/internal/model"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// ErrNotFound is returned when a domain or scan is not in the store
+var ErrNotFound = errors.New("scan not found")
+
+// scansBucket holds one nested bucket per domain, keyed by scan ID
+var scansBucket = []byte("scans")
+
+// Store persists scan reports in a local bbolt database
+type Store struct {
+	db *bolt.DB
+}
+
+// Entry summarizes a stored scan without its full contents
+type Entry struct {
+	ID          uint64    `json:"id"`
+	Domain      string    `json:"domain"`
+	ScannedAt   time.Time `json:"scanned_at"`
+	Profile     string    `json:"profile"`
+	Interrupted bool      `json:"interrupted"`
+	Completed   int       `json:"modules_completed"`
+	Total       int       `json:"modules_total"`
+}
+
+// DomainSummary describes the scans stored for a single domain
+type DomainSummary struct {
+	Domain   string    `json:"domain"`
+	Scans    int       `json:"scans"`
+	LastScan time.Time `json:"last_scan"`
+}
+
+// DefaultPath returns ~/.gomain_analysis/history.db
+func DefaultPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "history.db"
+	}
+	return filepath.Join(home, ".gomain_analysis", "history.db")
+}
+
+// Open opens or creates the database at path
+func Open(path string) (*Store, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create history directory: %v", err)
+	}
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open history database %s: %v", path, err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(scansBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to initialize history database: %v", err)
+	}
+	return &Store{db: db}, nil
+}
+
+// Close closes the database
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Save stores the report and returns its scan ID, which increases with every
+// scan of the same domain
+func (s *Store) Save(r *model.DomainReport) (uint64, error) {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode scan: %v", err)
+	}
+
+	var id uint64
+	err = s.db.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.Bucket(scansBucket).CreateBucketIfNotExists([]byte(r.Domain))
+		if err != nil {
+			return err
+		}
+		if id, err = bucket.NextSequence(); err != nil {
+			return err
+		}
+		return bucket.Put(itob(id), data)
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to save scan of %s: %v", r.Domain, err)
+	}
+	return id, nil
+}
+
+// List returns every stored scan of domain, oldest first
+func (s *Store) List(domain string) ([]Entry, error) {
+	var entries []Entry
+	err := s.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(scansBucket).Bucket([]byte(domain))
+		if bucket == nil {
+			return nil
+		}
+		return bucket.ForEach(func(k, v []byte) error {
+			var r model.DomainReport
+			if err := json.Unmarshal(v, &r); err != nil {
+				return fmt.Errorf("failed to decode scan %d: %v", btoi(k), err)
+			}
+			entries = append(entries, newEntry(btoi(k), &r))
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list scans of %s: %v", domain, err)
+	}
+	return entries, nil
+}
+
+// Domains returns every domain with at least one stored scan
+func (s *Store) Domains() ([]DomainSummary, error) {
+	var domains []DomainSummary
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(scansBucket).ForEachBucket(func(name []byte) error {
+			bucket := tx.Bucket(scansBucket).Bucket(name)
+			summary := DomainSummary{Domain: string(name), Scans: bucket.Stats().KeyN}
+			if _, v := bucket.Cursor().Last(); v != nil {
+				var r model.DomainReport
+				if err := json.Unmarshal(v, &r); err == nil {
+					summary.LastScan = r.GeneratedAt
+				}
+			}
+			domains = append(domains, summary)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list domains: %v", err)
+	}
+	sort.Slice(domains, func(i, j int) bool { return domains[i].Domain < domains[j].Domain })
+	return domains, nil
+}
+
+// Get returns a stored scan. An id of 0 selects the most recent scan.
+func (s *Store) Get(domain string, id uint64) (*model.DomainReport, uint64, error) {
+	var r model.DomainReport
+	err := s.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(scansBucket).Bucket([]byte(domain))
+		if bucket == nil {
+			return ErrNotFound
+		}
+		var v []byte
+		if id == 0 {
+			var k []byte
+			k, v = bucket.Cursor().Last()
+			if k != nil {
+				id = btoi(k)
+			}
+		} else {
+			v = bucket.Get(itob(id))
+		}
+		if v == nil {
+			return ErrNotFound
+		}
+		return json.Unmarshal(v, &r)
+	})
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil, 0, err
+		}
+		return nil, 0, fmt.Errorf("failed to load scan of %s: %v", domain, err)
+	}
+	return &r, id, nil
+}
+
+func newEntry(id uint64, r *model.DomainReport) Entry {
+	entry := Entry{
+		ID:          id,
+		Domain:      r.Domain,
+		ScannedAt:   r.GeneratedAt,
+		Profile:     r.Profile,
+		Interrupted: r.Interrupted,
+		Total:       len(r.Modules),
+	}
+	for _, m := range r.Modules {
+		if m.Status == model.StatusCompleted {
+			entry.Completed++
+		}
+	}
+	return entry
+}
+
+func itob(v uint64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, v)
+	return b
+}
+
+func btoi(b []byte) uint64 {
+	return binary.BigEndian.Uint64(b)
+}
@@ -0,0 +1,99 @@
+package target
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+
+	"github.com/qepting91/gomain_analysis/internal/model"
+
+	"golang.org/x/net/idna"
+	"golang.org/x/net/publicsuffix"
+)
+
+// profile converts Unicode names to punycode with the same rules browsers
+// use for lookups, but without rejecting underscores, which are common in
+// real DNS names such as _dmarc or service records
+var profile = idna.New(
+	idna.MapForLookup(),
+	idna.Transitional(false),
+	idna.StrictDomainName(false),
+	idna.BidiRule(),
+)
+
+// Parse normalizes user input into a scan target. It accepts bare host
+// names, host:port, URLs with any path, upper case and Unicode (IDN) names,
+// and IP addresses.
+func Parse(input string) (model.Target, error) {
+	t := model.Target{Input: input}
+	raw := strings.TrimSpace(input)
+	if raw == "" {
+		return t, fmt.Errorf("empty target")
+	}
+
+	// Parse everything as a URL so host:port and paths are handled alike
+	hasScheme := strings.Contains(raw, "://")
+	if !hasScheme {
+		raw = "https://" + raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return t, fmt.Errorf("invalid target %q: %v", input, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return t, fmt.Errorf("invalid target %q: unsupported scheme %q", input, u.Scheme)
+	}
+
+	host := strings.TrimSuffix(u.Hostname(), ".")
+	if host == "" {
+		return t, fmt.Errorf("invalid target %q: no host name", input)
+	}
+
+	if ip := net.ParseIP(host); ip != nil {
+		t.Host = ip.String()
+		t.IsIP = true
+	} else {
+		ascii, err := profile.ToASCII(host)
+		if err != nil {
+			return t, fmt.Errorf("invalid host name %q: %v", host, err)
+		}
+		t.Host = strings.ToLower(ascii)
+		if !strings.Contains(t.Host, ".") {
+			return t, fmt.Errorf("invalid target %q: %q is not a fully qualified domain name", input, t.Host)
+		}
+		apex, err := publicsuffix.EffectiveTLDPlusOne(t.Host)
+		if err != nil {
+			return t, fmt.Errorf("invalid target %q: %v", input, err)
+		}
+		t.Apex = apex
+	}
+
+	// Keep the scheme, port and path of an explicit URL for the web module;
+	// anything else is fetched over HTTPS at the root
+	base := &url.URL{Scheme: "https", Host: t.Host, Path: "/"}
+	if t.IsIP && strings.Contains(t.Host, ":") {
+		base.Host = "[" + t.Host + "]"
+	}
+	if port := u.Port(); port != "" {
+		base.Host = net.JoinHostPort(t.Host, port)
+	}
+	if hasScheme {
+		base.Scheme = u.Scheme
+		if u.Path != "" {
+			base.Path = u.Path
+		}
+		base.RawQuery = u.RawQuery
+	}
+	t.URL = base.String()
+	return t, nil
+}
+
+// Normalize returns just the normalized host of input
+func Normalize(input string) (string, error) {
+	t, err := Parse(input)
+	if err != nil {
+		return "", err
+	}
+	return t.Host, nil
+}
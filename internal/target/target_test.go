@@ -0,0 +1,44 @@
+package target
+
+import (
+	"testing"
+
+	"github.com/qepting91/gomain_analysis/internal/model"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input string
+		want  model.Target
+	}{
+		{"Example.COM", model.Target{Host: "example.com", Apex: "example.com", URL: "https://example.com/"}},
+		{"  example.com.  ", model.Target{Host: "example.com", Apex: "example.com", URL: "https://example.com/"}},
+		{"www.example.co.uk:8443", model.Target{Host: "www.example.co.uk", Apex: "example.co.uk", URL: "https://www.example.co.uk:8443/"}},
+		{"http://example.com/login?next=1", model.Target{Host: "example.com", Apex: "example.com", URL: "http://example.com/login?next=1"}},
+		{"example.com/ignored/path", model.Target{Host: "example.com", Apex: "example.com", URL: "https://example.com/"}},
+		{"bücher.example", model.Target{Host: "xn--bcher-kva.example", Apex: "xn--bcher-kva.example", URL: "https://xn--bcher-kva.example/"}},
+		{"_dmarc.example.com", model.Target{Host: "_dmarc.example.com", Apex: "example.com", URL: "https://_dmarc.example.com/"}},
+		{"203.0.113.5", model.Target{Host: "203.0.113.5", IsIP: true, URL: "https://203.0.113.5/"}},
+		{"[2001:DB8::1]:443", model.Target{Host: "2001:db8::1", IsIP: true, URL: "https://[2001:db8::1]:443/"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := Parse(tt.input)
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.input, err)
+			}
+			tt.want.Input = tt.input
+			if got != tt.want {
+				t.Errorf("Parse(%q) = %+v, want %+v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, input := range []string{"", "   ", "localhost", "ftp://example.com", "http://", "exa mple.com"} {
+		if got, err := Parse(input); err == nil {
+			t.Errorf("Parse(%q) = %+v, want an error", input, got)
+		}
+	}
+}
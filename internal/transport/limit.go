@@ -0,0 +1,211 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"math"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// limiter paces requests per destination host. A host that answered with
+// Retry-After is paused for every caller, not just the one that was told.
+type limiter struct {
+	defaultQPS float64
+	hostQPS    map[string]float64
+
+	mu    sync.Mutex
+	hosts map[string]*hostLimit
+}
+
+type hostLimit struct {
+	rate  *rate.Limiter
+	until time.Time
+}
+
+func newLimiter(defaultQPS float64, hostQPS map[string]float64) *limiter {
+	normalized := make(map[string]float64, len(hostQPS))
+	for host, qps := range hostQPS {
+		normalized[strings.ToLower(strings.TrimSuffix(host, "."))] = qps
+	}
+	return &limiter{defaultQPS: defaultQPS, hostQPS: normalized, hosts: make(map[string]*hostLimit)}
+}
+
+// qps returns the limit for host: an entry for the host itself or its closest
+// parent domain, else the default. Zero or less means unlimited.
+func (l *limiter) qps(host string) float64 {
+	for name := host; name != ""; {
+		if qps, ok := l.hostQPS[name]; ok {
+			return qps
+		}
+		i := strings.IndexByte(name, '.')
+		if i < 0 {
+			break
+		}
+		name = name[i+1:]
+	}
+	return l.defaultQPS
+}
+
+func (l *limiter) get(host string) *hostLimit {
+	host = strings.ToLower(host)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	h, ok := l.hosts[host]
+	if !ok {
+		limit := rate.Inf
+		burst := 1
+		if qps := l.qps(host); qps > 0 {
+			limit = rate.Limit(qps)
+			burst = int(math.Max(1, math.Ceil(qps)))
+		}
+		h = &hostLimit{rate: rate.NewLimiter(limit, burst)}
+		l.hosts[host] = h
+	}
+	return h
+}
+
+// Wait blocks until a request to host is allowed or ctx is done
+func (l *limiter) Wait(ctx context.Context, host string) error {
+	h := l.get(host)
+	l.mu.Lock()
+	pause := time.Until(h.until)
+	l.mu.Unlock()
+	if pause > 0 {
+		if err := sleep(ctx, pause); err != nil {
+			return err
+		}
+	}
+	return h.rate.Wait(ctx)
+}
+
+// Pause holds back all requests to host for d
+func (l *limiter) Pause(host string, d time.Duration) {
+	h := l.get(host)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if until := time.Now().Add(d); until.After(h.until) {
+		h.until = until
+	}
+}
+
+// retryPolicy decides whether and when a failed request is sent again
+type retryPolicy struct {
+	maxRetries int
+	backoff    time.Duration
+	maxWait    time.Duration
+}
+
+// shouldRetry reports whether a response or error is worth another attempt
+func shouldRetry(resp *http.Response, err error) bool {
+	if err != nil {
+		var netErr net.Error
+		return errors.As(err, &netErr) && netErr.Timeout()
+	}
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+}
+
+// delay returns how long to wait before retry number attempt (starting at 0).
+// Retry-After wins over the exponential backoff; ok is false when the server
+// asks for a longer wait than maxWait allows.
+func (p retryPolicy) delay(attempt int, resp *http.Response) (d time.Duration, ok bool) {
+	if resp != nil {
+		if after, found := retryAfter(resp.Header.Get("Retry-After")); found {
+			return after, after <= p.maxWait
+		}
+	}
+	d = p.backoff << attempt
+	// Up to 25% jitter keeps parallel workers from retrying in lockstep
+	d += time.Duration(rand.Int63n(int64(d)/4 + 1))
+	if d > p.maxWait {
+		d = p.maxWait
+	}
+	return d, true
+}
+
+// retryAfter parses a Retry-After header given in seconds or as an HTTP date
+func retryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(strings.TrimSpace(value)); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second, true
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		d := time.Until(t)
+		if d < 0 {
+			d = 0
+		}
+		return d, true
+	}
+	return 0, false
+}
+
+// roundTrip sends req through next, pacing it by host and retrying
+// throttled or failed attempts
+func (s *state) roundTrip(next http.RoundTripper, req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	host := req.URL.Hostname()
+	replayable := req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+
+	for attempt := 0; ; attempt++ {
+		if err := s.limiter.Wait(ctx, host); err != nil {
+			return nil, err
+		}
+		// Retries send a copy with a fresh body; a RoundTripper must not
+		// modify the caller's request
+		send := req
+		if attempt > 0 {
+			send = req.Clone(ctx)
+			if req.GetBody != nil {
+				body, err := req.GetBody()
+				if err != nil {
+					return nil, err
+				}
+				send.Body = body
+			}
+		}
+
+		resp, err := next.RoundTrip(send)
+		if attempt >= s.retry.maxRetries || !replayable || !shouldRetry(resp, err) {
+			return resp, err
+		}
+		wait, ok := s.retry.delay(attempt, resp)
+		if resp != nil && resp.StatusCode == http.StatusTooManyRequests {
+			s.limiter.Pause(host, min(wait, s.retry.maxWait))
+		}
+		if !ok {
+			return resp, err
+		}
+		if resp != nil {
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+			resp.Body.Close()
+			log.Printf("Retrying %s in %s after status %d", req.URL, wait.Round(time.Millisecond), resp.StatusCode)
+		} else {
+			log.Printf("Retrying %s in %s after error: %v", req.URL, wait.Round(time.Millisecond), err)
+		}
+		if err := sleep(ctx, wait); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
@@ -0,0 +1,191 @@
+package transport
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRetryAfter(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Duration
+		ok    bool
+	}{
+		{"", 0, false},
+		{"0", 0, true},
+		{"120", 2 * time.Minute, true},
+		{" 5 ", 5 * time.Second, true},
+		{"-1", 0, false},
+		{"soon", 0, false},
+		{time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat), 0, true},
+	}
+	for _, tt := range tests {
+		got, ok := retryAfter(tt.value)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("retryAfter(%q) = %s, %v; want %s, %v", tt.value, got, ok, tt.want, tt.ok)
+		}
+	}
+
+	// An HTTP date in the future is a wait until then
+	got, ok := retryAfter(time.Now().Add(time.Minute).UTC().Format(http.TimeFormat))
+	if !ok || got <= 55*time.Second || got > time.Minute {
+		t.Errorf("retryAfter(date in a minute) = %s, %v", got, ok)
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	p := retryPolicy{maxRetries: 3, backoff: 100 * time.Millisecond, maxWait: time.Second}
+	withRetryAfter := func(value string) *http.Response {
+		return &http.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{"Retry-After": {value}}}
+	}
+	tests := []struct {
+		name     string
+		attempt  int
+		resp     *http.Response
+		min, max time.Duration
+		ok       bool
+	}{
+		{"first backoff", 0, nil, 100 * time.Millisecond, 125 * time.Millisecond, true},
+		{"backoff doubles", 2, nil, 400 * time.Millisecond, 500 * time.Millisecond, true},
+		{"backoff is capped", 10, nil, time.Second, time.Second, true},
+		{"Retry-After wins", 0, withRetryAfter("1"), time.Second, time.Second, true},
+		{"Retry-After too long", 0, withRetryAfter("60"), time.Minute, time.Minute, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, ok := p.delay(tt.attempt, tt.resp)
+			if d < tt.min || d > tt.max || ok != tt.ok {
+				t.Errorf("delay = %s, %v; want %s..%s, %v", d, ok, tt.min, tt.max, tt.ok)
+			}
+		})
+	}
+}
+
+func TestLimiterQPS(t *testing.T) {
+	l := newLimiter(2, map[string]float64{"Example.com.": 5, "api.example.com": 0.5})
+	tests := []struct {
+		host string
+		want float64
+	}{
+		{"example.com", 5},
+		{"www.example.com", 5},
+		{"api.example.com", 0.5},
+		{"v1.api.example.com", 0.5},
+		{"notexample.com", 2},
+		{"example.org", 2},
+	}
+	for _, tt := range tests {
+		if got := l.qps(tt.host); got != tt.want {
+			t.Errorf("qps(%s) = %v, want %v", tt.host, got, tt.want)
+		}
+	}
+}
+
+func TestLimiterPause(t *testing.T) {
+	l := newLimiter(0, nil)
+	l.Pause("Example.com", 50*time.Millisecond)
+	// A shorter pause must not cut the longer one short
+	l.Pause("example.com", time.Millisecond)
+
+	start := time.Now()
+	if err := l.Wait(context.Background(), "example.com"); err != nil {
+		t.Fatalf("Wait: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 40*time.Millisecond {
+		t.Errorf("Wait returned after %s, want the 50ms pause", elapsed)
+	}
+
+	start = time.Now()
+	if err := l.Wait(context.Background(), "example.org"); err != nil {
+		t.Fatalf("Wait: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 20*time.Millisecond {
+		t.Errorf("another host waited %s", elapsed)
+	}
+
+	l.Pause("example.net", time.Hour)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := l.Wait(ctx, "example.net"); err == nil {
+		t.Error("Wait ignored the cancelled context")
+	}
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestRoundTripRetries(t *testing.T) {
+	s := &state{
+		limiter: newLimiter(0, nil),
+		retry:   retryPolicy{maxRetries: 2, backoff: time.Millisecond, maxWait: 10 * time.Millisecond},
+	}
+	var bodies []string
+	var sent []*http.Request
+	next := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		body, _ := io.ReadAll(req.Body)
+		bodies = append(bodies, string(body))
+		sent = append(sent, req)
+		status := http.StatusServiceUnavailable
+		if len(sent) == 3 {
+			status = http.StatusOK
+		}
+		return &http.Response{StatusCode: status, Header: http.Header{}, Body: http.NoBody}, nil
+	})
+
+	req, err := http.NewRequest(http.MethodPost, "https://example.com/api", strings.NewReader("payload"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := s.roundTrip(next, req)
+	if err != nil {
+		t.Fatalf("roundTrip: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("status = %d after %d attempts", resp.StatusCode, len(sent))
+	}
+	if got := strings.Join(bodies, ","); got != "payload,payload,payload" {
+		t.Errorf("bodies sent = %s, want the payload on every attempt", got)
+	}
+	for i, r := range sent[1:] {
+		if r == req {
+			t.Errorf("retry %d reused the caller's request", i+1)
+		}
+	}
+}
+
+func TestRoundTripGivesUp(t *testing.T) {
+	s := &state{
+		limiter: newLimiter(0, nil),
+		retry:   retryPolicy{maxRetries: 1, backoff: time.Millisecond, maxWait: 10 * time.Millisecond},
+	}
+	attempts := 0
+	next := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		attempts++
+		return &http.Response{StatusCode: http.StatusInternalServerError, Header: http.Header{}, Body: http.NoBody}, nil
+	})
+	req, _ := http.NewRequest(http.MethodGet, "https://example.com/", nil)
+	resp, err := s.roundTrip(next, req)
+	if err != nil || resp.StatusCode != http.StatusInternalServerError {
+		t.Errorf("roundTrip = %v, %v; want the last 500 response", resp, err)
+	}
+	if attempts != 2 {
+		t.Errorf("%d attempts, want 2", attempts)
+	}
+
+	// A 404 is final
+	attempts = 0
+	next = roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		attempts++
+		return &http.Response{StatusCode: http.StatusNotFound, Header: http.Header{}, Body: http.NoBody}, nil
+	})
+	if _, err := s.roundTrip(next, req); err != nil || attempts != 1 {
+		t.Errorf("404: %d attempts, err %v", attempts, err)
+	}
+}
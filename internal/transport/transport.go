@@ -0,0 +1,309 @@
+package transport
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/qepting91/gomain_analysis/internal/audit"
+	"github.com/qepting91/gomain_analysis/internal/cache"
+	"github.com/qepting91/gomain_analysis/internal/evidence"
+	"github.com/qepting91/gomain_analysis/internal/replay"
+
+	"golang.org/x/net/proxy"
+)
+
+// TorProxy is the SOCKS address of a local Tor daemon. The socks5h scheme
+// makes Tor resolve host names so no DNS query leaks around it.
+const TorProxy = "socks5h://127.0.0.1:9050"
+
+// Options describe how every outbound connection is made
+type Options struct {
+	// Proxy is an http://, https://, socks5:// or socks5h:// URL, or "tor".
+	// When empty the standard HTTP_PROXY/HTTPS_PROXY variables apply to HTTP.
+	Proxy string
+	// UserAgent, when set, replaces the User-Agent of every HTTP request
+	UserAgent string
+	// Insecure disables TLS certificate verification
+	Insecure bool
+	// MinTLSVersion is "1.0", "1.1", "1.2" or "1.3"; empty keeps Go's default
+	MinTLSVersion string
+	// CACertFile adds the PEM certificates in the file to the system roots,
+	// e.g. for an intercepting egress proxy
+	CACertFile string
+
+	// RateLimit is the default number of requests per second sent to any
+	// single host; zero means unlimited
+	RateLimit float64
+	// HostRateLimits overrides RateLimit for a host and its subdomains
+	HostRateLimits map[string]float64
+	// MaxRetries is how often a request answered with 429 or 5xx, or that
+	// timed out, is sent again
+	MaxRetries int
+	// RetryBackoff is the first retry delay; it doubles with every attempt
+	RetryBackoff time.Duration
+	// MaxRetryWait caps a single delay. A Retry-After asking for more gives up.
+	MaxRetryWait time.Duration
+}
+
+// state is an immutable snapshot of the configured transport
+type state struct {
+	opts    Options
+	http    *http.Transport
+	dial    func(ctx context.Context, network, addr string) (net.Conn, error)
+	limiter *limiter
+	retry   retryPolicy
+}
+
+var current atomic.Pointer[state]
+
+func init() {
+	s, err := build(Options{})
+	if err != nil {
+		panic(err)
+	}
+	current.Store(s)
+}
+
+// Configure replaces the process-wide transport. Clients obtained earlier
+// from NewClient or RoundTripper pick up the change on their next request.
+func Configure(opts Options) error {
+	s, err := build(opts)
+	if err != nil {
+		return err
+	}
+	if old := current.Swap(s); old != nil {
+		old.http.CloseIdleConnections()
+	}
+	return nil
+}
+
+// Current returns the options in effect
+func Current() Options {
+	return current.Load().opts
+}
+
+// Proxied reports whether a proxy was configured, in which case traffic that
+// cannot go through it, such as UDP DNS, must not be sent at all
+func Proxied() bool {
+	return current.Load().opts.Proxy != ""
+}
+
+// NewClient returns an HTTP client using the shared transport. Requests
+// tagged with cache.WithSource are served from the cache while fresh,
+// everything is recorded or replayed when a replay session is active, and
+// responses are saved as evidence when the request context has a collector.
+func NewClient(timeout time.Duration) *http.Client {
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: evidence.Transport(replay.Transport(cache.Transport(RoundTripper()))),
+	}
+}
+
+// RoundTripper returns the shared transport without replay, for traffic
+// that is not part of a scan such as alert webhooks
+func RoundTripper() http.RoundTripper {
+	return roundTripper{}
+}
+
+// DialContext opens a TCP connection through the configured proxy. It is
+// used for non-HTTP protocols such as WHOIS on port 43.
+func DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	s := current.Load()
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		if err := s.limiter.Wait(ctx, host); err != nil {
+			return nil, err
+		}
+	}
+	return s.dial(ctx, network, addr)
+}
+
+type roundTripper struct{}
+
+func (roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	s := current.Load()
+	if s.opts.UserAgent != "" {
+		req = req.Clone(req.Context())
+		req.Header.Set("User-Agent", s.opts.UserAgent)
+	}
+	// Audited below the cache and replay layers, so only requests that
+	// actually leave the machine are logged, one entry per attempt
+	return s.roundTrip(audit.Transport(s.http), req)
+}
+
+func build(opts Options) (*state, error) {
+	tlsConfig, err := newTLSConfig(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	direct := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.TLSClientConfig = tlsConfig
+	s := &state{
+		opts:    opts,
+		http:    t,
+		dial:    direct.DialContext,
+		limiter: newLimiter(opts.RateLimit, opts.HostRateLimits),
+		retry: retryPolicy{
+			maxRetries: opts.MaxRetries,
+			backoff:    opts.RetryBackoff,
+			maxWait:    opts.MaxRetryWait,
+		},
+	}
+	if s.retry.backoff <= 0 {
+		s.retry.backoff = time.Second
+	}
+	if s.retry.maxWait <= 0 {
+		s.retry.maxWait = time.Minute
+	}
+
+	if opts.Proxy == "" {
+		return s, nil
+	}
+	raw := opts.Proxy
+	if strings.EqualFold(raw, "tor") {
+		raw = TorProxy
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid proxy URL %q", opts.Proxy)
+	}
+
+	switch u.Scheme {
+	case "http", "https":
+		t.Proxy = http.ProxyURL(u)
+		s.dial = (&connectDialer{proxy: u, forward: direct}).DialContext
+	case "socks5", "socks5h":
+		d, err := proxy.FromURL(u, direct)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create SOCKS5 dialer: %v", err)
+		}
+		cd, ok := d.(proxy.ContextDialer)
+		if !ok {
+			return nil, fmt.Errorf("SOCKS5 dialer does not support contexts")
+		}
+		t.Proxy = nil
+		t.DialContext = cd.DialContext
+		s.dial = cd.DialContext
+	default:
+		return nil, fmt.Errorf("unsupported proxy scheme %q (use http, https, socks5 or socks5h)", u.Scheme)
+	}
+	return s, nil
+}
+
+func newTLSConfig(opts Options) (*tls.Config, error) {
+	cfg := &tls.Config{InsecureSkipVerify: opts.Insecure}
+
+	switch opts.MinTLSVersion {
+	case "":
+	case "1.0":
+		cfg.MinVersion = tls.VersionTLS10
+	case "1.1":
+		cfg.MinVersion = tls.VersionTLS11
+	case "1.2":
+		cfg.MinVersion = tls.VersionTLS12
+	case "1.3":
+		cfg.MinVersion = tls.VersionTLS13
+	default:
+		return nil, fmt.Errorf("unsupported minimum TLS version %q", opts.MinTLSVersion)
+	}
+
+	if opts.CACertFile != "" {
+		pemData, err := os.ReadFile(opts.CACertFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA certificate file: %v", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pemData) {
+			return nil, fmt.Errorf("no certificates found in %s", opts.CACertFile)
+		}
+		cfg.RootCAs = pool
+	}
+	return cfg, nil
+}
+
+// connectDialer tunnels raw TCP connections through an HTTP proxy with CONNECT
+type connectDialer struct {
+	proxy   *url.URL
+	forward *net.Dialer
+}
+
+func (d *connectDialer) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	proxyAddr := d.proxy.Host
+	if d.proxy.Port() == "" {
+		port := "80"
+		if d.proxy.Scheme == "https" {
+			port = "443"
+		}
+		proxyAddr = net.JoinHostPort(d.proxy.Hostname(), port)
+	}
+
+	conn, err := d.forward.DialContext(ctx, "tcp", proxyAddr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to proxy %s: %v", proxyAddr, err)
+	}
+	if d.proxy.Scheme == "https" {
+		conn = tls.Client(conn, &tls.Config{ServerName: d.proxy.Hostname()})
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: addr},
+		Host:   addr,
+		Header: make(http.Header),
+	}
+	if user := d.proxy.User; user != nil {
+		password, _ := user.Password()
+		auth := base64.StdEncoding.EncodeToString([]byte(user.Username() + ":" + password))
+		req.Header.Set("Proxy-Authorization", "Basic "+auth)
+	}
+	if err := req.Write(conn); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to send CONNECT to proxy: %v", err)
+	}
+
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, req)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to read CONNECT response from proxy: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		conn.Close()
+		return nil, fmt.Errorf("proxy refused CONNECT to %s: %s", addr, resp.Status)
+	}
+	conn.SetDeadline(time.Time{})
+
+	if br.Buffered() > 0 {
+		return &bufferedConn{Conn: conn, r: br}, nil
+	}
+	return conn, nil
+}
+
+// bufferedConn serves bytes the proxy sent right after its CONNECT response
+type bufferedConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func (c *bufferedConn) Read(p []byte) (int, error) {
+	return c.r.Read(p)
+}
@@ -1,30 +1,56 @@
 package wayback
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
-	"log"
 	"net/http"
+	"net/url"
+
+	"github.com/qepting91/gomain_analysis/internal/cache"
+	"github.com/qepting91/gomain_analysis/internal/model"
+	"github.com/qepting91/gomain_analysis/internal/transport"
 
 	"github.com/seekr-osint/wayback-machine-golang/wayback"
 )
 
-// FetchSnapshots retrieves available snapshots and returns them as formatted strings
-func FetchSnapshots(domain string) []string {
-	client := &http.Client{}
-	snapshots, err := wayback.GetSnapshotData("https://"+domain, client)
+// AvailabilityURL is the Wayback Machine availability API endpoint
+const AvailabilityURL = "https://archive.org/wayback/available"
+
+// httpClient is reused across Wayback lookups
+var httpClient = transport.NewClient(0)
+
+// FetchSnapshots retrieves available snapshots for the domain
+func FetchSnapshots(ctx context.Context, domain string) ([]model.WaybackSnapshot, error) {
+	apiURL := fmt.Sprintf("%s?url=%s", AvailabilityURL, url.QueryEscape("https://"+domain))
+	req, err := http.NewRequestWithContext(cache.WithSource(ctx, cache.SourceWayback), http.MethodGet, apiURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Wayback request for domain %s: %v", domain, err)
+	}
+
+	resp, err := httpClient.Do(req)
 	if err != nil {
-		log.Printf("Error fetching snapshots for domain %s: %v", domain, err)
-		return nil
+		return nil, fmt.Errorf("failed to fetch snapshots for domain %s: %v", domain, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch snapshots for domain %s, status code: %d", domain, resp.StatusCode)
+	}
+
+	var snapshots wayback.SnapshotData
+	if err := json.NewDecoder(resp.Body).Decode(&snapshots); err != nil {
+		return nil, fmt.Errorf("failed to parse Wayback response for domain %s: %v", domain, err)
 	}
 
-	var results []string
+	var results []model.WaybackSnapshot
 	if snapshots.ArchivedSnapshots.Closest.Available {
-		snapshotInfo := fmt.Sprintf("URL: %s\nTimestamp: %s\nStatus: %s",
-			snapshots.ArchivedSnapshots.Closest.URL,
-			snapshots.ArchivedSnapshots.Closest.Timestamp,
-			snapshots.ArchivedSnapshots.Closest.Status)
-		results = append(results, snapshotInfo)
+		results = append(results, model.WaybackSnapshot{
+			URL:       snapshots.ArchivedSnapshots.Closest.URL,
+			Timestamp: snapshots.ArchivedSnapshots.Closest.Timestamp,
+			Status:    snapshots.ArchivedSnapshots.Closest.Status,
+		})
 	}
 
-	return results
+	return results, nil
 }
@@ -1,29 +1,58 @@
 package whois
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"strings"
+	"time"
+
+	"github.com/qepting91/gomain_analysis/internal/audit"
+	"github.com/qepting91/gomain_analysis/internal/cache"
+	"github.com/qepting91/gomain_analysis/internal/evidence"
+	"github.com/qepting91/gomain_analysis/internal/replay"
+	"github.com/qepting91/gomain_analysis/internal/transport"
 
 	"github.com/domainr/whois"
 )
 
+// client sends port 43 queries and WHOIS-over-HTTP lookups through the
+// shared transport, so they honour the configured proxy
+var client = &whois.Client{
+	DialContext: transport.DialContext,
+	HTTPClient:  transport.NewClient(0),
+}
+
 // LookupWHOIS retrieves WHOIS information for the given domain
-func LookupWHOIS(domain string) (string, error) {
+func LookupWHOIS(ctx context.Context, domain string) (string, error) {
 	// Perform WHOIS lookup
 	req, err := whois.NewRequest(domain)
 	if err != nil {
 		return "", fmt.Errorf("failed to create WHOIS request for domain %s: %v", domain, err)
 	}
 
-	res, err := whois.DefaultClient.Fetch(req)
+	body, err := replay.Do(replay.KindWHOIS, domain, func() ([]byte, error) {
+		return cache.Do(cache.SourceWHOIS, domain, func() ([]byte, error) {
+			entry := audit.Entry{Time: time.Now(), Kind: audit.KindWHOIS, Query: domain, Server: req.Host}
+			res, err := client.FetchContext(ctx, req)
+			audit.Record(ctx, entry, err)
+			if err != nil {
+				return nil, err
+			}
+			return []byte(res.String()), nil
+		})
+	})
 	if err != nil {
 		return "", fmt.Errorf("failed to fetch WHOIS information for domain %s: %v", domain, err)
 	}
 
 	log.Printf("Successfully retrieved WHOIS information for domain: %s", domain)
+	evidence.Save(ctx, evidence.Artifact{
+		Kind:   evidence.KindWHOIS,
+		Source: "whois://" + req.Host + "/" + domain,
+	}, body)
 
 	// Process response and return it as a string
-	whoisInfo := strings.TrimSpace(res.String())
+	whoisInfo := strings.TrimSpace(string(body))
 	return whoisInfo, nil
 }
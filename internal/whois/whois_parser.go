@@ -0,0 +1,104 @@
+package whois
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/qepting91/gomain_analysis/internal/model"
+)
+
+// Field names used by the common registry and registrar WHOIS formats
+var (
+	registrarKeys  = []string{"registrar", "sponsoring registrar", "registrar name"}
+	createdKeys    = []string{"creation date", "created", "created on", "registered on", "domain registration date"}
+	updatedKeys    = []string{"updated date", "last updated", "last modified", "changed"}
+	expiresKeys    = []string{"registry expiry date", "registrar registration expiration date", "expiration date", "expiry date", "expires", "expires on", "paid-till"}
+	nameServerKeys = []string{"name server", "nserver", "nameserver", "name servers"}
+	statusKeys     = []string{"domain status", "status"}
+)
+
+// dateLayouts are tried in order when parsing WHOIS dates
+var dateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05Z",
+	"2006-01-02T15:04:05.0Z",
+	"2006-01-02T15:04:05.000Z",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+	"2006.01.02",
+	"02-Jan-2006",
+	"02.01.2006",
+	"January 2 2006",
+}
+
+// Parse extracts the commonly used fields from a raw WHOIS response.
+// Fields that cannot be found are left empty.
+func Parse(raw string) model.WHOISInfo {
+	info := model.WHOISInfo{Raw: raw}
+	fields := make(map[string][]string)
+	for _, line := range strings.Split(raw, "\n") {
+		key, value, ok := strings.Cut(strings.TrimSpace(line), ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		fields[key] = append(fields[key], value)
+	}
+
+	info.Registrar = first(fields, registrarKeys)
+	info.CreatedAt = parseDate(first(fields, createdKeys))
+	info.UpdatedAt = parseDate(first(fields, updatedKeys))
+	info.ExpiresAt = parseDate(first(fields, expiresKeys))
+	info.NameServers = collect(fields, nameServerKeys, func(v string) string {
+		// Some registries append the glue address after the host name
+		return strings.ToLower(strings.TrimSuffix(strings.Fields(v)[0], "."))
+	})
+	info.Status = collect(fields, statusKeys, func(v string) string {
+		// Drop the ICANN explanation URL that follows EPP status codes
+		return strings.Fields(v)[0]
+	})
+	return info
+}
+
+func first(fields map[string][]string, keys []string) string {
+	for _, key := range keys {
+		if values := fields[key]; len(values) > 0 {
+			return values[0]
+		}
+	}
+	return ""
+}
+
+func collect(fields map[string][]string, keys []string, normalize func(string) string) []string {
+	seen := make(map[string]bool)
+	var values []string
+	for _, key := range keys {
+		for _, value := range fields[key] {
+			value = normalize(value)
+			if !seen[value] {
+				seen[value] = true
+				values = append(values, value)
+			}
+		}
+	}
+	sort.Strings(values)
+	return values
+}
+
+func parseDate(value string) *time.Time {
+	if value == "" {
+		return nil
+	}
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			t = t.UTC()
+			return &t
+		}
+	}
+	return nil
+}